@@ -0,0 +1,160 @@
+// Package audit computes and checks the hash chain of the audit log.
+//
+// Each entry's hash is SHA-256, or HMAC-SHA-256 when a key is configured,
+// over the previous entry's hash and the entry's canonical JSON without its
+// own hash. The first entry chains from Genesis. Without a key anyone can
+// recompute the chain, so tampering is only evident against a head hash
+// recorded elsewhere; with a key, rewriting the chain also needs the key.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+// Genesis is the previous hash of the first entry.
+const Genesis = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Payload is the canonical encoding of e that its hash covers: e as JSON
+// with Hash left empty.
+func Payload(e models.AuditEntry) ([]byte, error) {
+	e.Hash = ""
+	return json.Marshal(e)
+}
+
+// Hash returns the hash of e chained onto e.PrevHash.
+func Hash(key []byte, e models.AuditEntry) (string, error) {
+	payload, err := Payload(e)
+	if err != nil {
+		return "", err
+	}
+
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write([]byte(e.PrevHash))
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verifier checks entries one at a time, in sequence order.
+type Verifier struct {
+	key  []byte
+	next int64
+	prev string
+}
+
+func NewVerifier(key []byte) *Verifier {
+	return &Verifier{key: key, next: 1, prev: Genesis}
+}
+
+// Check reports why e does not follow the entries checked so far, or nil.
+func (v *Verifier) Check(e models.AuditEntry) error {
+	if e.Seq != v.next {
+		return fmt.Errorf("expected entry %d, found %d: entries were removed or reordered", v.next, e.Seq)
+	}
+	if e.PrevHash != v.prev {
+		return fmt.Errorf("previous hash does not match entry %d", e.Seq-1)
+	}
+	want, err := Hash(v.key, e)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(want), []byte(e.Hash)) {
+		return fmt.Errorf("hash does not match the entry's contents")
+	}
+	v.next++
+	v.prev = e.Hash
+	return nil
+}
+
+// Head returns the hash of the last entry checked.
+func (v *Verifier) Head() string {
+	return v.prev
+}
+
+// Checked returns how many entries passed.
+func (v *Verifier) Checked() int64 {
+	return v.next - 1
+}
+
+// Scan feeds entries to fn in sequence order, as storage.Store.ScanAudit
+// does.
+type Scan func(fn func(models.AuditEntry) error) error
+
+// Verify walks the whole chain and reports the first entry that breaks it.
+// An error is returned only when the entries could not be read.
+func Verify(key []byte, scan Scan) (models.AuditVerification, error) {
+	v := NewVerifier(key)
+	out := models.AuditVerification{Valid: true}
+	err := scan(func(e models.AuditEntry) error {
+		if problem := v.Check(e); problem != nil {
+			seq := e.Seq
+			out.Valid, out.FailedSeq, out.Problem = false, &seq, problem.Error()
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return out, err
+	}
+	out.Entries = v.Checked()
+	out.HeadHash = v.Head()
+	out.CheckedAt = time.Now().UTC()
+	return out, nil
+}
+
+var errStop = errors.New("stop")
+
+// Export formats.
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+var csvHeader = []string{
+	"seq", "kind", "analysisId", "type", "user", "site", "model", "promptVersion",
+	"createdAt", "error", "request", "response", "prevHash", "hash",
+}
+
+// Export writes the entries scan yields to w, one JSON object per line or
+// as CSV with the request and response as JSON text. Every field is kept so
+// the export can be verified on its own.
+func Export(w io.Writer, format string, scan Scan) error {
+	if format == FormatCSV {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		err := scan(func(e models.AuditEntry) error {
+			return cw.Write([]string{
+				strconv.FormatInt(e.Seq, 10), e.Kind, e.AnalysisID, e.Type, e.User, e.Site, e.Model, e.PromptVersion,
+				e.CreatedAt.Format(time.RFC3339Nano), e.Error, string(e.Request), string(e.Response), e.PrevHash, e.Hash,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	enc := json.NewEncoder(w)
+	return scan(func(e models.AuditEntry) error {
+		return enc.Encode(e)
+	})
+}
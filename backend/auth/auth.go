@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"pcst-ai/backend/models"
+)
+
+var (
+	// ErrNoToken means the request carried no bearer token.
+	ErrNoToken = errors.New("authentication required")
+	// ErrInvalidToken means a token was not accepted by any authenticator.
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrNotForMe means an authenticator does not handle a token, so the
+	// next one should try.
+	ErrNotForMe = errors.New("token not issued by this authenticator")
+)
+
+// Authenticator turns a bearer token into the caller it identifies.
+// Implementations return ErrNotForMe for tokens they do not handle.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (models.Principal, error)
+}
+
+// Chain tries each authenticator in turn.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(ctx context.Context, token string) (models.Principal, error) {
+	if token == "" {
+		return models.Principal{}, ErrNoToken
+	}
+	for _, a := range c {
+		p, err := a.Authenticate(ctx, token)
+		if errors.Is(err, ErrNotForMe) {
+			continue
+		}
+		if err != nil {
+			return models.Principal{}, err
+		}
+		return p, nil
+	}
+	return models.Principal{}, ErrInvalidToken
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"pcst-ai/backend/models"
+)
+
+// LocalIssuerName is the iss claim of tokens signed by this server.
+const LocalIssuerName = "pcst-ai"
+
+// UserSource looks up local accounts by ID.
+type UserSource interface {
+	GetUser(ctx context.Context, id string) (models.User, error)
+}
+
+// Local signs and checks HS256 tokens for local accounts. Every check
+// reloads the account, so disabling it or changing its role takes effect
+// immediately.
+type Local struct {
+	secret []byte
+	ttl    time.Duration
+	users  UserSource
+}
+
+func NewLocal(secret []byte, ttl time.Duration, users UserSource) *Local {
+	return &Local{secret: secret, ttl: ttl, users: users}
+}
+
+// Issue signs a token for u.
+func (l *Local) Issue(u models.User) (string, time.Time, error) {
+	expires := time.Now().Add(l.ttl).UTC().Truncate(time.Second)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    LocalIssuerName,
+		Subject:   u.ID,
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(expires),
+	})
+	signed, err := token.SignedString(l.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("signing token: %w", err)
+	}
+	return signed, expires, nil
+}
+
+func (l *Local) Authenticate(ctx context.Context, token string) (models.Principal, error) {
+	if issuer(token) != LocalIssuerName {
+		return models.Principal{}, ErrNotForMe
+	}
+
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return l.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(LocalIssuerName), jwt.WithExpirationRequired())
+	if err != nil {
+		return models.Principal{}, ErrInvalidToken
+	}
+
+	u, err := l.users.GetUser(ctx, claims.Subject)
+	if err != nil || u.Disabled {
+		return models.Principal{}, ErrInvalidToken
+	}
+	return LocalPrincipal(u), nil
+}
+
+// LocalPrincipal describes a local account as a caller.
+func LocalPrincipal(u models.User) models.Principal {
+	return models.Principal{Subject: u.ID, Username: u.Username, Name: u.Name, Role: u.Role, Sites: u.Sites, Source: "local"}
+}
+
+// issuer reads a token's iss claim without verifying it, to route the token
+// to the authenticator that can check it.
+func issuer(token string) string {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return ""
+	}
+	return claims.Issuer
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"pcst-ai/backend/models"
+)
+
+// OIDCConfig describes an external issuer. RoleClaim is a dotted path to
+// a string or list claim holding role names, e.g. "roles" or
+// "realm_access.roles". DefaultRole is given to callers whose token names no
+// known role; when empty such callers are refused. SiteClaim is a path to
+// the site IDs the caller may use; a token without it gets DefaultSites,
+// and with neither the caller may use no site.
+type OIDCConfig struct {
+	Issuer       string
+	Audience     string
+	RoleClaim    string
+	DefaultRole  string
+	SiteClaim    string
+	DefaultSites []string
+}
+
+// OIDC checks tokens signed by an OpenID Connect issuer against the keys it
+// publishes.
+type OIDC struct {
+	config   OIDCConfig
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDC fetches the issuer's discovery document.
+func NewOIDC(ctx context.Context, config OIDCConfig) (*OIDC, error) {
+	provider, err := oidc.NewProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discovering OIDC issuer %s: %w", config.Issuer, err)
+	}
+	if config.RoleClaim == "" {
+		config.RoleClaim = "roles"
+	}
+	if config.SiteClaim == "" {
+		config.SiteClaim = "sites"
+	}
+	verifier := provider.Verifier(&oidc.Config{
+		ClientID:          config.Audience,
+		SkipClientIDCheck: config.Audience == "",
+	})
+	return &OIDC{config: config, verifier: verifier}, nil
+}
+
+func (o *OIDC) Authenticate(ctx context.Context, token string) (models.Principal, error) {
+	if strings.TrimSuffix(issuer(token), "/") != strings.TrimSuffix(o.config.Issuer, "/") {
+		return models.Principal{}, ErrNotForMe
+	}
+
+	idToken, err := o.verifier.Verify(ctx, token)
+	if err != nil {
+		return models.Principal{}, ErrInvalidToken
+	}
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return models.Principal{}, ErrInvalidToken
+	}
+
+	role := o.role(claims)
+	if role == "" {
+		return models.Principal{}, ErrInvalidToken
+	}
+	username := firstString(claims, "preferred_username", "email", "sub")
+	sites := claimValues(claims, o.config.SiteClaim)
+	if len(sites) == 0 {
+		sites = o.config.DefaultSites
+	}
+	return models.Principal{
+		Subject:  idToken.Subject,
+		Username: username,
+		Name:     firstString(claims, "name"),
+		Role:     role,
+		Sites:    sites,
+		Source:   "oidc",
+	}, nil
+}
+
+// role picks the highest known role named by the role claim.
+func (o *OIDC) role(claims map[string]interface{}) string {
+	named := map[string]bool{}
+	for _, r := range claimValues(claims, o.config.RoleClaim) {
+		named[r] = true
+	}
+	for _, r := range Roles {
+		if named[r] {
+			return r
+		}
+	}
+	return o.config.DefaultRole
+}
+
+// claimValues reads the claim at a dotted path as a list of lowercase
+// names. A string claim is split on spaces.
+func claimValues(claims map[string]interface{}, path string) []string {
+	var value interface{} = claims
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			value = nil
+			break
+		}
+		value = m[key]
+	}
+
+	values := []string{}
+	switch v := value.(type) {
+	case string:
+		for _, s := range strings.Fields(v) {
+			values = append(values, strings.ToLower(s))
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, strings.ToLower(s))
+			}
+		}
+	}
+	return values
+}
+
+func firstString(claims map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := claims[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
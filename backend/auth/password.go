@@ -0,0 +1,23 @@
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+// HashPassword returns a bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// DummyHash is a bcrypt hash at the default cost, of no account's password.
+// Checking against it when an account does not exist keeps sign-in as slow
+// as for a real account, so response times do not reveal which usernames
+// exist.
+const DummyHash = "$2a$10$p04VBv9F9yqmfdQbCaorQefx5LHi.3MQNHQwqJdPy22D2WJu3BEqi"
+
+// CheckPassword reports whether password matches hash.
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,59 @@
+// Package auth authenticates API callers, either with local accounts and
+// tokens signed by this server or with tokens from an external OpenID
+// Connect issuer, and decides what each role may do.
+package auth
+
+import "pcst-ai/backend/models"
+
+// Permission names an action routes are guarded by.
+type Permission string
+
+const (
+	// Analyze runs the analyzers and reads their history.
+	Analyze Permission = "analyze"
+	// ManageReference edits the catalog, error codes, loops and documents.
+	ManageReference Permission = "reference:manage"
+	// ViewReports reads aggregated feedback.
+	ViewReports Permission = "reports:view"
+	// ApproveSafety signs off safety assessments.
+	ApproveSafety Permission = "safety:approve"
+	// ViewAudit verifies and exports the audit log.
+	ViewAudit Permission = "audit:view"
+	// ViewConfig reads the effective server configuration.
+	ViewConfig Permission = "config:view"
+	// ManageUsers administers local accounts.
+	ManageUsers Permission = "users:manage"
+)
+
+// Roles lists the roles in the order a token naming several is resolved:
+// the first one found wins.
+var Roles = []string{models.RoleAdmin, models.RoleEngineer, models.RoleSupervisor, models.RoleTechnician}
+
+var rolePermissions = map[string][]Permission{
+	models.RoleTechnician: {Analyze},
+	models.RoleSupervisor: {Analyze, ViewReports, ViewAudit},
+	models.RoleEngineer:   {Analyze, ViewReports, ManageReference, ApproveSafety},
+}
+
+// Allowed reports whether role grants p. Admins are granted everything.
+func Allowed(role string, p Permission) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	for _, granted := range rolePermissions[role] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether role is one of Roles.
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
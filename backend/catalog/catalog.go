@@ -0,0 +1,412 @@
+// Package catalog stores the equipment types technicians can troubleshoot.
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+var (
+	//go:embed seed.json
+	seedData []byte
+
+	//go:embed knowledge.json
+	knowledgeData []byte
+)
+
+var (
+	ErrNotFound  = errors.New("equipment not found")
+	ErrConflict  = errors.New("equipment conflicts with an existing entry")
+	ErrInvalidID = errors.New("invalid equipment id")
+)
+
+// Store is the catalog's persistence boundary.
+type Store interface {
+	List() ([]models.Equipment, error)
+	Get(id string) (models.Equipment, error)
+	Create(in models.EquipmentInput) (models.Equipment, error)
+	Update(id string, in models.EquipmentInput) (models.Equipment, error)
+	Delete(id string) (models.Equipment, error)
+	SetKnowledge(id string, pack *models.KnowledgePack) (models.Equipment, error)
+}
+
+// FileStore keeps the catalog in a JSON file, rewriting it on every change.
+// A missing file is created from the built-in seed list.
+type FileStore struct {
+	path  string
+	mu    sync.RWMutex
+	items []models.Equipment
+}
+
+// OpenFile loads the catalog at path. An empty path keeps the seed list in
+// memory only.
+func OpenFile(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case path == "" || errors.Is(err, os.ErrNotExist):
+		items, err := Seed()
+		if err != nil {
+			return nil, err
+		}
+		s.items = items
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("reading equipment catalog: %w", err)
+	default:
+		if err := json.Unmarshal(data, &s.items); err != nil {
+			return nil, fmt.Errorf("parsing equipment catalog: %w", err)
+		}
+		// Catalogs written before knowledge packs existed pick up the
+		// built-in packs for equipment that has none.
+		added, err := applyKnowledge(s.items)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			if err := s.save(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// applyKnowledge attaches the built-in knowledge packs to entries that have
+// none and reports whether anything changed.
+func applyKnowledge(items []models.Equipment) (bool, error) {
+	var packs map[string]*models.KnowledgePack
+	if err := json.Unmarshal(knowledgeData, &packs); err != nil {
+		return false, fmt.Errorf("parsing seed knowledge packs: %w", err)
+	}
+
+	added := false
+	for i := range items {
+		if items[i].Knowledge != nil {
+			continue
+		}
+		if pack, ok := packs[items[i].ID]; ok {
+			items[i].Knowledge = pack
+			added = true
+		}
+	}
+	return added, nil
+}
+
+// Seed returns the built-in equipment list.
+func Seed() ([]models.Equipment, error) {
+	var items []models.Equipment
+	if err := json.Unmarshal(seedData, &items); err != nil {
+		return nil, fmt.Errorf("parsing seed catalog: %w", err)
+	}
+	if _, err := applyKnowledge(items); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for i := range items {
+		items[i].CreatedAt = now
+		items[i].UpdatedAt = now
+	}
+	return items, nil
+}
+
+func (s *FileStore) List() ([]models.Equipment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]models.Equipment, len(s.items))
+	copy(items, s.items)
+	return items, nil
+}
+
+func (s *FileStore) Get(id string) (models.Equipment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.index(id); i >= 0 {
+		return s.items[i], nil
+	}
+	return models.Equipment{}, ErrNotFound
+}
+
+func (s *FileStore) Create(in models.EquipmentInput) (models.Equipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := in.ID
+	if id == "" {
+		id = Slugify(in.Name)
+	}
+	item := fromInput(id, in)
+	if err := s.checkConflicts(item, -1); err != nil {
+		return models.Equipment{}, err
+	}
+
+	now := time.Now().UTC()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	s.items = append(s.items, item)
+
+	if err := s.save(); err != nil {
+		s.items = s.items[:len(s.items)-1]
+		return models.Equipment{}, err
+	}
+	return item, nil
+}
+
+// Update replaces an entry. The ID is immutable; an ID in the input is
+// ignored.
+func (s *FileStore) Update(id string, in models.EquipmentInput) (models.Equipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return models.Equipment{}, ErrNotFound
+	}
+
+	previous := s.items[i]
+	item := fromInput(previous.ID, in)
+	if err := s.checkConflicts(item, i); err != nil {
+		return models.Equipment{}, err
+	}
+	item.CreatedAt = previous.CreatedAt
+	item.UpdatedAt = time.Now().UTC()
+	s.items[i] = item
+
+	if err := s.save(); err != nil {
+		s.items[i] = previous
+		return models.Equipment{}, err
+	}
+	return item, nil
+}
+
+func (s *FileStore) Delete(id string) (models.Equipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return models.Equipment{}, ErrNotFound
+	}
+
+	previous := s.items
+	item := s.items[i]
+	s.items = append(append([]models.Equipment{}, s.items[:i]...), s.items[i+1:]...)
+
+	if err := s.save(); err != nil {
+		s.items = previous
+		return models.Equipment{}, err
+	}
+	return item, nil
+}
+
+// SetKnowledge replaces an entry's knowledge pack; nil removes it.
+func (s *FileStore) SetKnowledge(id string, pack *models.KnowledgePack) (models.Equipment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return models.Equipment{}, ErrNotFound
+	}
+
+	previous := s.items[i]
+	s.items[i].Knowledge = pack
+	s.items[i].UpdatedAt = time.Now().UTC()
+
+	if err := s.save(); err != nil {
+		s.items[i] = previous
+		return models.Equipment{}, err
+	}
+	return s.items[i], nil
+}
+
+func (s *FileStore) index(id string) int {
+	for i, item := range s.items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkConflicts rejects an entry whose ID, name or aliases would make
+// Resolve ambiguous. skip is the index of the entry being replaced.
+func (s *FileStore) checkConflicts(item models.Equipment, skip int) error {
+	if item.ID == "" {
+		return fmt.Errorf("%w: the name has no letters or digits to derive one from, so give an id", ErrInvalidID)
+	}
+
+	keys := append([]string{item.ID, item.Name}, item.Aliases...)
+	for i, other := range s.items {
+		if i == skip {
+			continue
+		}
+		if other.ID == item.ID {
+			return fmt.Errorf("%w: id %q is taken", ErrConflict, item.ID)
+		}
+		for _, key := range keys {
+			if matches(other, key) {
+				return fmt.Errorf("%w: %q already refers to %s", ErrConflict, key, other.ID)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("writing equipment catalog: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing equipment catalog: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing equipment catalog: %w", err)
+	}
+	return nil
+}
+
+func fromInput(id string, in models.EquipmentInput) models.Equipment {
+	return models.Equipment{
+		ID:           id,
+		Name:         strings.TrimSpace(in.Name),
+		Category:     in.Category,
+		Description:  in.Description,
+		FailureModes: nonNil(in.FailureModes),
+		Vendors:      nonNilVendors(in.Vendors),
+		Aliases:      nonNil(in.Aliases),
+		Knowledge:    in.Knowledge,
+	}
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func nonNilVendors(vendors []models.Vendor) []models.Vendor {
+	if vendors == nil {
+		return []models.Vendor{}
+	}
+	return vendors
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify derives a stable ID from a display name.
+func Slugify(name string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func matches(item models.Equipment, key string) bool {
+	key = strings.TrimSpace(key)
+	if strings.EqualFold(item.ID, key) || strings.EqualFold(item.Name, key) {
+		return true
+	}
+	for _, alias := range item.Aliases {
+		if strings.EqualFold(alias, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// PromptContext renders an entry's failure modes and knowledge pack as a
+// prompt section. It returns an empty string when there is nothing to add.
+func PromptContext(item models.Equipment) string {
+	var b strings.Builder
+	writeList := func(title string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%s:\n", title)
+		for _, v := range values {
+			fmt.Fprintf(&b, "- %s\n", v)
+		}
+	}
+
+	if k := item.Knowledge; k != nil && k.MeasurementPrinciple != "" {
+		fmt.Fprintf(&b, "Measurement principle: %s\n", k.MeasurementPrinciple)
+	}
+	writeList("Typical failure modes", item.FailureModes)
+	if k := item.Knowledge; k != nil {
+		writeList("Diagnostics", k.Diagnostics)
+		writeList("Typical checks", k.TypicalChecks)
+		writeList("Cautions", k.Cautions)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Resolve finds the entry a technician meant by an ID, name or alias.
+func Resolve(store Store, key string) (models.Equipment, bool, error) {
+	items, err := store.List()
+	if err != nil {
+		return models.Equipment{}, false, err
+	}
+	for _, item := range items {
+		if matches(item, key) {
+			return item, true, nil
+		}
+	}
+	return models.Equipment{}, false, nil
+}
+
+// Filter returns the entries in a category whose name, ID or aliases
+// contain query. Empty arguments match everything; results are sorted by
+// name.
+func Filter(items []models.Equipment, category, query string) []models.Equipment {
+	query = strings.ToLower(strings.TrimSpace(query))
+	out := make([]models.Equipment, 0, len(items))
+	for _, item := range items {
+		if category != "" && item.Category != category {
+			continue
+		}
+		if query != "" && !contains(item, query) {
+			continue
+		}
+		out = append(out, item)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func contains(item models.Equipment, query string) bool {
+	if strings.Contains(strings.ToLower(item.Name), query) || strings.Contains(item.ID, query) {
+		return true
+	}
+	for _, alias := range item.Aliases {
+		if strings.Contains(strings.ToLower(alias), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+// Command audit checks and exports the audit log outside the server, for
+// scheduled verification and for handing records to regulators.
+//
+//	go run ./cmd/audit verify
+//	go run ./cmd/audit export -format csv -from 2024-01-01 -to 2024-04-01 -out q1.csv
+//
+// It reads the storage DSN and audit HMAC key from the same configuration
+// file (PCST_CONFIG) and environment as the server. verify exits with
+// status 1 when the chain is broken.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"pcst-ai/backend/audit"
+	"pcst-ai/backend/config"
+	"pcst-ai/backend/handlers"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/storage"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cfg, err := config.Load(nil)
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	handlers.SetConfig(cfg)
+
+	store, err := handlers.OpenAnalysisStore()
+	if err != nil {
+		log.Fatal("Failed to open storage: ", err)
+	}
+	defer store.Close()
+	key := []byte(cfg.Audit.HMACKey)
+
+	switch os.Args[1] {
+	case "verify":
+		os.Exit(verify(store, key))
+	case "export":
+		export(store, os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: audit verify | audit export [-format jsonl|csv] [-from time] [-to time] [-out file]")
+	os.Exit(2)
+}
+
+func verify(store storage.Store, key []byte) int {
+	ctx := context.Background()
+	result, err := audit.Verify(key, func(fn func(models.AuditEntry) error) error {
+		return store.ScanAudit(ctx, storage.AuditQuery{AllSites: true}, fn)
+	})
+	if err != nil {
+		log.Print("Failed to read the audit log: ", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(result)
+	if !result.Valid {
+		return 1
+	}
+	return 0
+}
+
+func export(store storage.Store, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", audit.FormatJSONL, "jsonl or csv")
+	from := fs.String("from", "", "first append time to include, RFC 3339 or YYYY-MM-DD")
+	to := fs.String("to", "", "append time to stop before, RFC 3339 or YYYY-MM-DD")
+	out := fs.String("out", "", "file to write instead of standard output")
+	fs.Parse(args)
+
+	if *format != audit.FormatJSONL && *format != audit.FormatCSV {
+		log.Fatal("-format must be jsonl or csv")
+	}
+	var q storage.AuditQuery
+	var err error
+	if q.From, err = parseTime(*from); err != nil {
+		log.Fatal("-from: ", err)
+	}
+	if q.To, err = parseTime(*to); err != nil {
+		log.Fatal("-to: ", err)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			log.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	err = audit.Export(w, *format, func(fn func(models.AuditEntry) error) error {
+		return store.ScanAudit(ctx, q, fn)
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		log.Fatal("Failed to export the audit log: ", err)
+	}
+}
+
+func parseTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
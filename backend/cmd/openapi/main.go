@@ -0,0 +1,77 @@
+// Command openapi writes the OpenAPI document derived from the models
+// package. With -check it instead fails when the committed document or the
+// documented routes no longer match the code.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/config"
+	"pcst-ai/backend/openapi"
+	"pcst-ai/backend/router"
+)
+
+func main() {
+	out := flag.String("out", "openapi/openapi.json", "path of the committed OpenAPI document")
+	check := flag.Bool("check", false, "compare instead of writing; exit non-zero on drift")
+	flag.Parse()
+
+	data, err := openapi.Build().JSON()
+	if err != nil {
+		log.Fatal("Failed to build OpenAPI document:", err)
+	}
+
+	if !*check {
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			log.Fatal("Failed to write OpenAPI document:", err)
+		}
+		return
+	}
+
+	failed := false
+
+	gin.SetMode(gin.ReleaseMode)
+	registered := map[string]bool{}
+	for _, route := range router.New(config.Default()).Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	documented := map[string]bool{}
+	for _, key := range openapi.RouteKeys() {
+		documented[key] = true
+		if !registered[key] {
+			fmt.Printf("documented but not registered: %s\n", key)
+			failed = true
+		}
+	}
+	var missing []string
+	for key := range registered {
+		if !documented[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		fmt.Printf("registered but not documented: %s\n", key)
+		failed = true
+	}
+
+	committed, err := os.ReadFile(*out)
+	if err != nil {
+		fmt.Printf("reading %s: %v\n", *out, err)
+		failed = true
+	} else if !bytes.Equal(committed, data) {
+		fmt.Printf("%s is out of date; run: go run ./cmd/openapi\n", *out)
+		failed = true
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	fmt.Println("OpenAPI document is up to date")
+}
@@ -0,0 +1,87 @@
+// Command swaggerui vendors the swagger-ui-dist files the API docs page
+// embeds, so the page loads nothing from a CDN. Run it from backend to
+// upgrade, and commit the result:
+//
+//	go run ./cmd/swaggerui -version 5.17.14
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// vendored are the package files the docs page needs, with its licence.
+var vendored = []string{"swagger-ui-bundle.js", "swagger-ui.css", "LICENSE"}
+
+func main() {
+	log.SetFlags(0)
+	version := flag.String("version", "5.17.14", "swagger-ui-dist version")
+	out := flag.String("out", "openapi/swagger-ui", "directory the docs page embeds")
+	flag.Parse()
+
+	url := fmt.Sprintf("https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-%s.tgz", *version)
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		log.Fatal("Failed to download swagger-ui-dist: ", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("Failed to download swagger-ui-dist: %s returned %s", url, resp.Status)
+	}
+
+	if err := extract(resp.Body, *out); err != nil {
+		log.Fatal("Failed to unpack swagger-ui-dist: ", err)
+	}
+	if err := os.WriteFile(filepath.Join(*out, "VERSION"), []byte(*version+"\n"), 0o644); err != nil {
+		log.Fatal("Failed to write VERSION: ", err)
+	}
+	fmt.Printf("swagger-ui-dist %s written to %s\n", *version, *out)
+}
+
+// extract writes the vendored files of an npm package tarball to dir.
+func extract(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool, len(vendored))
+	for _, name := range vendored {
+		wanted["package/"+name] = true
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !wanted[header.Name] {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(header.Name)), data, 0o644); err != nil {
+			return err
+		}
+		delete(wanted, header.Name)
+	}
+	for name := range wanted {
+		return fmt.Errorf("package has no %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+// Package config is the server's typed configuration. Settings come from
+// built-in defaults, then an optional YAML (or JSON) file, then environment
+// variables, then command-line flags, each layer overriding the one before.
+//
+// Every setting has an environment variable, named in its env tag. Settings
+// that are not secrets also have a flag named after the variable in
+// lowercase with dashes, e.g. -storage-dsn for STORAGE_DSN.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"time"
+
+	"pcst-ai/backend/auth"
+)
+
+// Config is the effective configuration. Fields tagged secret are hidden by
+// Redacted.
+type Config struct {
+	Server   Server   `yaml:"server" json:"server"`
+	Provider Provider `yaml:"provider" json:"provider"`
+	Storage  Storage  `yaml:"storage" json:"storage"`
+	Data     Data     `yaml:"data" json:"data"`
+	Auth     Auth     `yaml:"auth" json:"auth"`
+	Audit    Audit    `yaml:"audit" json:"audit"`
+	Logging  Logging  `yaml:"logging" json:"logging"`
+	Tracing  Tracing  `yaml:"tracing" json:"tracing"`
+	Features Features `yaml:"features" json:"features"`
+}
+
+// Server is the HTTP listener. TLS is served when both files are set.
+// TrustedProxies lists the proxies whose X-Forwarded-For is believed; when
+// empty the peer address is the client. Readiness checks are reused for
+// ReadinessCacheTTL, so frequent probes do not each call the provider. On
+// SIGINT or SIGTERM requests already running get ShutdownGracePeriod to
+// finish before they are cancelled.
+type Server struct {
+	Addr           string   `yaml:"addr" json:"addr" env:"LISTEN_ADDR"`
+	TLSCertFile    string   `yaml:"tlsCertFile" json:"tlsCertFile" env:"TLS_CERT_FILE"`
+	TLSKeyFile     string   `yaml:"tlsKeyFile" json:"tlsKeyFile" env:"TLS_KEY_FILE"`
+	CORSOrigins    []string `yaml:"corsOrigins" json:"corsOrigins" env:"CORS_ORIGINS"`
+	TrustedProxies []string `yaml:"trustedProxies" json:"trustedProxies" env:"TRUSTED_PROXIES"`
+	ReadTimeout    Duration `yaml:"readTimeout" json:"readTimeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout   Duration `yaml:"writeTimeout" json:"writeTimeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout    Duration `yaml:"idleTimeout" json:"idleTimeout" env:"HTTP_IDLE_TIMEOUT"`
+
+	ShutdownGracePeriod Duration `yaml:"shutdownGracePeriod" json:"shutdownGracePeriod" env:"SHUTDOWN_GRACE_PERIOD"`
+	ReadinessCacheTTL   Duration `yaml:"readinessCacheTTL" json:"readinessCacheTTL" env:"READINESS_CACHE_TTL"`
+}
+
+// Provider is the model the analyzers generate with. Gemini is the only
+// provider so far. A batch assessment runs at most BatchConcurrency
+// analyses at once, so one batch does not flood the provider.
+type Provider struct {
+	Name             string   `yaml:"name" json:"name" env:"PROVIDER"`
+	APIKey           string   `yaml:"apiKey" json:"apiKey" env:"GEMINI_API_KEY" secret:"true"`
+	Model            string   `yaml:"model" json:"model" env:"GEMINI_MODEL"`
+	EmbeddingModel   string   `yaml:"embeddingModel" json:"embeddingModel" env:"DOCUMENT_EMBEDDING_MODEL"`
+	Timeout          Duration `yaml:"timeout" json:"timeout" env:"PROVIDER_TIMEOUT"`
+	BatchConcurrency int      `yaml:"batchConcurrency" json:"batchConcurrency" env:"PROVIDER_BATCH_CONCURRENCY"`
+}
+
+// Storage is the database analysis records, users and the audit log live
+// in: a SQLite path or sqlite:// URL, or a postgres:// URL.
+type Storage struct {
+	DSN string `yaml:"dsn" json:"dsn" env:"STORAGE_DSN" secret:"dsn"`
+}
+
+// Data names the reference data files. An empty RiskMatrixFile uses the
+// built-in 5x5 matrix and an empty SitesFile configures a single site.
+type Data struct {
+	SitesFile            string `yaml:"sitesFile" json:"sitesFile" env:"SITES_FILE"`
+	EquipmentCatalogFile string `yaml:"equipmentCatalogFile" json:"equipmentCatalogFile" env:"EQUIPMENT_CATALOG_FILE"`
+	ErrorCodesFile       string `yaml:"errorCodesFile" json:"errorCodesFile" env:"ERROR_CODES_FILE"`
+	LoopDiagramsFile     string `yaml:"loopDiagramsFile" json:"loopDiagramsFile" env:"LOOP_DIAGRAMS_FILE"`
+	DocumentIndexFile    string `yaml:"documentIndexFile" json:"documentIndexFile" env:"DOCUMENT_INDEX_FILE"`
+	RiskMatrixFile       string `yaml:"riskMatrixFile" json:"riskMatrixFile" env:"RISK_MATRIX_FILE"`
+}
+
+type Auth struct {
+	Disabled      bool     `yaml:"disabled" json:"disabled" env:"AUTH_DISABLED"`
+	TokenSecret   string   `yaml:"tokenSecret" json:"tokenSecret" env:"AUTH_TOKEN_SECRET" secret:"true"`
+	TokenTTL      Duration `yaml:"tokenTTL" json:"tokenTTL" env:"AUTH_TOKEN_TTL"`
+	AdminUsername string   `yaml:"adminUsername" json:"adminUsername" env:"AUTH_ADMIN_USERNAME"`
+	AdminPassword string   `yaml:"adminPassword" json:"adminPassword" env:"AUTH_ADMIN_PASSWORD" secret:"true"`
+	OIDC          OIDC     `yaml:"oidc" json:"oidc"`
+}
+
+// OIDC accepts tokens from an external issuer when Issuer is set.
+type OIDC struct {
+	Issuer       string   `yaml:"issuer" json:"issuer" env:"AUTH_OIDC_ISSUER"`
+	Audience     string   `yaml:"audience" json:"audience" env:"AUTH_OIDC_AUDIENCE"`
+	RoleClaim    string   `yaml:"roleClaim" json:"roleClaim" env:"AUTH_OIDC_ROLE_CLAIM"`
+	DefaultRole  string   `yaml:"defaultRole" json:"defaultRole" env:"AUTH_OIDC_DEFAULT_ROLE"`
+	SiteClaim    string   `yaml:"siteClaim" json:"siteClaim" env:"AUTH_OIDC_SITE_CLAIM"`
+	DefaultSites []string `yaml:"defaultSites" json:"defaultSites" env:"AUTH_OIDC_DEFAULT_SITES"`
+}
+
+type Audit struct {
+	HMACKey string `yaml:"hmacKey" json:"hmacKey" env:"AUDIT_HMAC_KEY" secret:"true"`
+}
+
+// Logging is the structured log on standard output. Format is json or
+// text. RedactUserText replaces text typed by users, and prompts built from
+// it, with its length.
+type Logging struct {
+	Format         string `yaml:"format" json:"format" env:"LOG_FORMAT"`
+	Level          string `yaml:"level" json:"level" env:"LOG_LEVEL"`
+	RedactUserText bool   `yaml:"redactUserText" json:"redactUserText" env:"LOG_REDACT_USER_TEXT"`
+}
+
+// Tracing exports OpenTelemetry spans over OTLP/HTTP to the collector at
+// Endpoint, such as http://otel-collector:4318. Without an endpoint no spans
+// are recorded. SampleRatio is the share of new traces kept; requests that
+// arrive with a trace context follow the caller's decision.
+type Tracing struct {
+	Endpoint    string  `yaml:"endpoint" json:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string  `yaml:"serviceName" json:"serviceName" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sampleRatio" json:"sampleRatio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Features switches optional behavior.
+type Features struct {
+	// LegacyRoutes serves the deprecated unversioned /api routes.
+	LegacyRoutes bool `yaml:"legacyRoutes" json:"legacyRoutes" env:"FEATURE_LEGACY_ROUTES"`
+	// APIDocs serves the interactive documentation at /api/v1/docs.
+	APIDocs bool `yaml:"apiDocs" json:"apiDocs" env:"FEATURE_API_DOCS"`
+	// DocumentRetrieval adds passages from ingested documents to prompts.
+	DocumentRetrieval bool `yaml:"documentRetrieval" json:"documentRetrieval" env:"FEATURE_DOCUMENT_RETRIEVAL"`
+	// Metrics serves Prometheus metrics at /metrics.
+	Metrics bool `yaml:"metrics" json:"metrics" env:"FEATURE_METRICS"`
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Addr:           ":8080",
+			CORSOrigins:    []string{"http://localhost:5173", "http://localhost:5174", "http://localhost:3000"},
+			TrustedProxies: []string{},
+			ReadTimeout:    Duration(30 * time.Second),
+			WriteTimeout:   Duration(3 * time.Minute),
+			IdleTimeout:    Duration(2 * time.Minute),
+
+			ShutdownGracePeriod: Duration(2 * time.Minute),
+			ReadinessCacheTTL:   Duration(30 * time.Second),
+		},
+		Provider: Provider{
+			Name:             "gemini",
+			Model:            "gemini-2.5-flash",
+			Timeout:          Duration(2 * time.Minute),
+			BatchConcurrency: 4,
+		},
+		Storage: Storage{DSN: "sqlite://data/pcst.db"},
+		Data: Data{
+			EquipmentCatalogFile: "data/equipment.json",
+			ErrorCodesFile:       "data/error-codes.json",
+			LoopDiagramsFile:     "data/loops.json",
+			DocumentIndexFile:    "data/documents.json",
+		},
+		Auth: Auth{
+			TokenTTL:      Duration(12 * time.Hour),
+			AdminUsername: "admin",
+		},
+		Logging: Logging{
+			Format:         "json",
+			Level:          "info",
+			RedactUserText: true,
+		},
+		Tracing: Tracing{
+			ServiceName: "pcst-ai",
+			SampleRatio: 1,
+		},
+		Features: Features{
+			LegacyRoutes:      true,
+			APIDocs:           true,
+			DocumentRetrieval: true,
+			Metrics:           true,
+		},
+	}
+}
+
+// Validate reports every setting that cannot work, joined into one error.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Addr == "" {
+		invalid("server.addr (LISTEN_ADDR) is required")
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		invalid("server.tlsCertFile and server.tlsKeyFile must be set together")
+	}
+	for _, path := range []string{c.Server.TLSCertFile, c.Server.TLSKeyFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			invalid("TLS file: %v", err)
+		}
+	}
+	for _, origin := range c.Server.CORSOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			invalid("server.corsOrigins: %q is not an origin such as https://pcst.example.com", origin)
+		}
+	}
+	for _, d := range []struct {
+		name  string
+		value Duration
+	}{
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.shutdownGracePeriod", c.Server.ShutdownGracePeriod},
+		{"provider.timeout", c.Provider.Timeout},
+		{"auth.tokenTTL", c.Auth.TokenTTL},
+	} {
+		if d.value <= 0 {
+			invalid("%s must be a positive duration such as 30s", d.name)
+		}
+	}
+	if c.Provider.Timeout > 0 && c.Server.WriteTimeout > 0 && c.Server.WriteTimeout <= c.Provider.Timeout {
+		invalid("server.writeTimeout (%s) must be longer than provider.timeout (%s) or answers are cut off", c.Server.WriteTimeout, c.Provider.Timeout)
+	}
+
+	if c.Server.ReadinessCacheTTL < 0 {
+		invalid("server.readinessCacheTTL must not be negative")
+	}
+
+	if c.Provider.Name != "gemini" {
+		invalid("provider.name must be gemini")
+	}
+	if c.Provider.Model == "" {
+		invalid("provider.model (GEMINI_MODEL) is required")
+	}
+	if c.Provider.BatchConcurrency < 1 {
+		invalid("provider.batchConcurrency must be at least 1")
+	}
+	if c.Storage.DSN == "" {
+		invalid("storage.dsn (STORAGE_DSN) is required")
+	}
+	if c.Auth.AdminPassword != "" && c.Auth.AdminUsername == "" {
+		invalid("auth.adminUsername (AUTH_ADMIN_USERNAME) is required with an admin password")
+	}
+	if role := c.Auth.OIDC.DefaultRole; role != "" && !auth.ValidRole(role) {
+		invalid("auth.oidc.defaultRole must be technician, engineer, supervisor or admin")
+	}
+	if c.Logging.Format != "json" && c.Logging.Format != "text" {
+		invalid("logging.format must be json or text")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		invalid("logging.level must be debug, info, warn or error")
+	}
+	if endpoint := c.Tracing.Endpoint; endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("tracing.endpoint: %q is not a collector URL such as http://otel-collector:4318", endpoint)
+		}
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sampleRatio must be from 0 to 1")
+	}
+
+	return errors.Join(errs...)
+}
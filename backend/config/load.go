@@ -0,0 +1,250 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration written as text such as "30s" in files, the
+// environment and JSON.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Load builds the configuration from args, which are command-line flags
+// without the program name, and validates it.
+//
+// -config names the file, also settable as PCST_CONFIG. -env-file names a
+// dotenv file whose variables are added to the environment without
+// replacing ones already set; without it .env and then ../.env are tried.
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("pcst-ai", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("PCST_CONFIG"), "YAML or JSON configuration file")
+	envFile := fs.String("env-file", "", "dotenv file to read environment variables from")
+
+	// Flags are only collected here and applied after the file and the
+	// environment, which they override.
+	given := map[string]string{}
+	c := Default()
+	for _, f := range fields(c) {
+		if f.secret != "" {
+			continue
+		}
+		fs.Var(&flagValue{name: f.flag, given: given, isBool: f.value.Kind() == reflect.Bool}, f.flag, "overrides "+f.env)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *envFile != "" {
+		if err := godotenv.Load(*envFile); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", *envFile, err)
+		}
+	} else if godotenv.Load(".env") != nil {
+		godotenv.Load("../.env")
+	}
+
+	if *configFile != "" {
+		if err := c.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// Empty variables count as unset, as they always have here.
+	for _, f := range fields(c) {
+		raw := os.Getenv(f.env)
+		if raw == "" && f.env == "LISTEN_ADDR" && os.Getenv("PORT") != "" {
+			// PORT predates LISTEN_ADDR and is still honored.
+			raw = ":" + os.Getenv("PORT")
+		}
+		if raw != "" {
+			if err := set(f.value, raw); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, f := range fields(c) {
+		if raw, ok := given[f.flag]; ok {
+			if err := set(f.value, raw); err != nil {
+				return nil, fmt.Errorf("-%s: %w", f.flag, err)
+			}
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading configuration: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// Redacted returns a copy safe to show: secrets that are set read
+// "[redacted]" and passwords are removed from the storage DSN.
+func (c *Config) Redacted() *Config {
+	out := *c
+	for _, f := range fields(&out) {
+		switch {
+		case f.secret == "dsn":
+			f.value.SetString(redactDSN(f.value.String()))
+		case f.secret != "" && f.value.String() != "":
+			f.value.SetString("[redacted]")
+		}
+	}
+	return &out
+}
+
+var dsnPassword = regexp.MustCompile(`(?i)(password=)\S+`)
+
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "redacted")
+			return u.String()
+		}
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}redacted")
+}
+
+// field is one setting, addressable for writing.
+type field struct {
+	env    string
+	flag   string
+	secret string
+	value  reflect.Value
+}
+
+// fields lists the settings of c in declaration order.
+func fields(c *Config) []field {
+	var out []field
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			fv := v.Field(i)
+			if env := sf.Tag.Get("env"); env != "" {
+				out = append(out, field{
+					env:    env,
+					flag:   strings.ReplaceAll(strings.ToLower(env), "_", "-"),
+					secret: sf.Tag.Get("secret"),
+					value:  fv,
+				})
+				continue
+			}
+			if fv.Kind() == reflect.Struct {
+				walk(fv)
+			}
+		}
+	}
+	walk(reflect.ValueOf(c).Elem())
+	return out
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// set parses raw into v. Lists are comma-separated.
+func set(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == durationType:
+		var d Duration
+		if err := d.UnmarshalText([]byte(raw)); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", raw)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		list := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// flagValue records a flag's raw value for Load to apply later.
+type flagValue struct {
+	name   string
+	given  map[string]string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil || f.given == nil {
+		return ""
+	}
+	return f.given[f.name]
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.given[f.name] = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
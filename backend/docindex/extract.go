@@ -0,0 +1,204 @@
+package docindex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	FormatPDF      = "pdf"
+	FormatMarkdown = "markdown"
+	FormatText     = "text"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported document format; upload PDF, Markdown or plain text")
+	ErrNoText      = errors.New("document has no extractable text")
+)
+
+// section is a run of text that shares a heading and page.
+type section struct {
+	Heading string
+	Page    int
+	Text    string
+}
+
+// DetectFormat decides how to read an upload from its name, falling back
+// to the content type.
+func DetectFormat(filename, contentType string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		return FormatPDF, nil
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".txt", ".text", ".log", ".csv":
+		return FormatText, nil
+	}
+	switch {
+	case strings.HasPrefix(contentType, "application/pdf"):
+		return FormatPDF, nil
+	case strings.HasPrefix(contentType, "text/markdown"):
+		return FormatMarkdown, nil
+	case strings.HasPrefix(contentType, "text/"):
+		return FormatText, nil
+	}
+	return "", ErrUnsupported
+}
+
+// extract returns a document's text split by heading or page, the page
+// count and any warnings about content that could not be read.
+func extract(format string, data []byte) ([]section, int, []string, error) {
+	switch format {
+	case FormatPDF:
+		return extractPDF(data)
+	case FormatMarkdown:
+		if !utf8.Valid(data) {
+			return nil, 0, nil, fmt.Errorf("%w: text is not UTF-8", ErrUnsupported)
+		}
+		return markdownSections(string(data)), 0, nil, nil
+	case FormatText:
+		if !utf8.Valid(data) {
+			return nil, 0, nil, fmt.Errorf("%w: text is not UTF-8", ErrUnsupported)
+		}
+		return []section{{Text: string(data)}}, 0, nil, nil
+	}
+	return nil, 0, nil, ErrUnsupported
+}
+
+var markdownHeading = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+
+func markdownSections(text string) []section {
+	var sections []section
+	current := section{}
+	var body strings.Builder
+	flush := func() {
+		current.Text = body.String()
+		if strings.TrimSpace(current.Text) != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if m := markdownHeading.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+			flush()
+			current = section{Heading: strings.TrimSpace(strings.TrimRight(m[1], "#"))}
+			continue
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+	return sections
+}
+
+// extractPDF reads the text layer page by page. Scanned pages have none and
+// are reported as warnings rather than failing the whole document.
+func extractPDF(data []byte) (sections []section, pages int, warnings []string, err error) {
+	// The PDF reader panics on some malformed files.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("reading PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("reading PDF: %w", err)
+	}
+
+	pages = reader.NumPage()
+	var empty []string
+	for i := 1; i <= pages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil || strings.TrimSpace(text) == "" {
+			empty = append(empty, fmt.Sprint(i))
+			continue
+		}
+		sections = append(sections, section{Page: i, Text: text})
+	}
+	if len(empty) > 0 {
+		warnings = append(warnings, fmt.Sprintf("no text layer on page(s) %s; scanned pages need OCR before ingestion", strings.Join(empty, ", ")))
+	}
+	return sections, pages, warnings, nil
+}
+
+const maxPassageChars = 1000
+
+var (
+	paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// chunk splits sections into passages of at most maxPassageChars, keeping
+// paragraphs together where they fit.
+func chunk(sections []section) []passage {
+	var passages []passage
+	for _, s := range sections {
+		var current strings.Builder
+		flush := func() {
+			if text := strings.TrimSpace(current.String()); text != "" {
+				passages = append(passages, passage{Section: s.Heading, Page: s.Page, Text: text})
+			}
+			current.Reset()
+		}
+
+		for _, para := range paragraphBreak.Split(s.Text, -1) {
+			para = strings.TrimSpace(whitespace.ReplaceAllString(para, " "))
+			if para == "" {
+				continue
+			}
+			for _, piece := range splitLong(para) {
+				if current.Len() > 0 && current.Len()+len(piece)+1 > maxPassageChars {
+					flush()
+				}
+				if current.Len() > 0 {
+					current.WriteString("\n")
+				}
+				current.WriteString(piece)
+			}
+		}
+		flush()
+	}
+	return passages
+}
+
+// splitLong breaks a paragraph longer than a passage at sentence ends, or
+// at spaces when a sentence is itself too long.
+func splitLong(para string) []string {
+	if len(para) <= maxPassageChars {
+		return []string{para}
+	}
+
+	var pieces []string
+	for len(para) > maxPassageChars {
+		window := para[:maxPassageChars]
+		cut := strings.LastIndex(window, ". ")
+		if cut < maxPassageChars/2 {
+			cut = strings.LastIndex(window, " ")
+		}
+		if cut <= 0 {
+			cut = maxPassageChars - 1
+			for cut > 0 && !utf8.RuneStart(para[cut+1]) {
+				cut--
+			}
+		}
+		pieces = append(pieces, strings.TrimSpace(para[:cut+1]))
+		para = strings.TrimSpace(para[cut+1:])
+	}
+	if para != "" {
+		pieces = append(pieces, para)
+	}
+	return pieces
+}
@@ -0,0 +1,483 @@
+// Package docindex ingests plant manuals, procedures and bulletins into a
+// local index and retrieves the passages relevant to a request. Retrieval
+// is keyword (BM25) based and works offline; an Embedder can be added for
+// hybrid semantic search.
+package docindex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+var ErrNotFound = errors.New("document not found")
+
+// Embedder turns texts into vectors for semantic search.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Meta is what the uploader says about a document.
+type Meta struct {
+	Title       string
+	Kind        string
+	Description string
+}
+
+type passage struct {
+	DocumentID string    `json:"documentId"`
+	Section    string    `json:"section,omitempty"`
+	Page       int       `json:"page,omitempty"`
+	Text       string    `json:"text"`
+	Vector     []float32 `json:"vector,omitempty"`
+
+	terms  map[string]int
+	length int
+}
+
+type indexFile struct {
+	Documents []models.Document `json:"documents"`
+	Passages  []passage         `json:"passages"`
+}
+
+// Index holds every ingested document and its passages in memory and in a
+// JSON file that is rewritten on every change.
+type Index struct {
+	path     string
+	embedder Embedder
+
+	mu        sync.RWMutex
+	documents []models.Document
+	passages  []passage
+	docFreq   map[string]int
+	totalLen  int
+}
+
+// Open loads the index at path. An empty path keeps it in memory only.
+func Open(path string) (*Index, error) {
+	ix := &Index{path: path, documents: []models.Document{}}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("reading document index: %w", err)
+		default:
+			var f indexFile
+			if err := json.Unmarshal(data, &f); err != nil {
+				return nil, fmt.Errorf("parsing document index: %w", err)
+			}
+			ix.documents = f.Documents
+			ix.passages = f.Passages
+		}
+	}
+	ix.rebuild()
+	return ix, nil
+}
+
+// SetEmbedder enables semantic search for documents ingested from now on.
+func (ix *Index) SetEmbedder(e Embedder) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.embedder = e
+}
+
+// Ingest extracts, splits and indexes a document. Uploading the same
+// content again replaces the earlier copy.
+func (ix *Index) Ingest(ctx context.Context, meta Meta, filename, format string, data []byte) (models.Document, error) {
+	sections, pages, warnings, err := extract(format, data)
+	if err != nil {
+		return models.Document{}, err
+	}
+	passages := chunk(sections)
+	if len(passages) == 0 {
+		return models.Document{}, ErrNoText
+	}
+
+	title := strings.TrimSpace(meta.Title)
+	if title == "" && format == FormatMarkdown && sections[0].Heading != "" {
+		title = sections[0].Heading
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	kind := meta.Kind
+	if kind == "" {
+		kind = models.DocumentKindOther
+	}
+	sum := sha256.Sum256(data)
+	doc := models.Document{
+		ID:          slug(title) + "-" + hex.EncodeToString(sum[:4]),
+		Title:       title,
+		Kind:        kind,
+		Description: strings.TrimSpace(meta.Description),
+		Filename:    filepath.Base(filename),
+		Format:      format,
+		Pages:       pages,
+		Passages:    len(passages),
+		Warnings:    warnings,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if doc.Warnings == nil {
+		doc.Warnings = []string{}
+	}
+	for i := range passages {
+		passages[i].DocumentID = doc.ID
+	}
+
+	ix.mu.RLock()
+	embedder := ix.embedder
+	ix.mu.RUnlock()
+	if embedder != nil {
+		texts := make([]string, len(passages))
+		for i, p := range passages {
+			texts[i] = p.Text
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			doc.Warnings = append(doc.Warnings, "embeddings unavailable, keyword search only: "+err.Error())
+		} else {
+			for i := range passages {
+				passages[i].Vector = vectors[i]
+			}
+			doc.Embedded = true
+		}
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	prevDocs, prevPassages := ix.documents, ix.passages
+	ix.removeLocked(doc.ID)
+	ix.documents = append(ix.documents, doc)
+	ix.passages = append(ix.passages, passages...)
+	if err := ix.save(); err != nil {
+		ix.documents, ix.passages = prevDocs, prevPassages
+		ix.rebuild()
+		return models.Document{}, err
+	}
+	ix.rebuild()
+	return doc, nil
+}
+
+// Check reports whether uploads can still be saved: the index file is
+// rewritten through a temporary file next to it.
+func (ix *Index) Check() error {
+	if ix.path == "" {
+		return nil
+	}
+	dir := filepath.Dir(ix.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("document index directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, ".check-*")
+	if err != nil {
+		return fmt.Errorf("document index directory: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func (ix *Index) List() []models.Document {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	docs := make([]models.Document, len(ix.documents))
+	copy(docs, ix.documents)
+	sort.SliceStable(docs, func(i, j int) bool { return docs[i].Title < docs[j].Title })
+	return docs
+}
+
+func (ix *Index) Get(id string) (models.Document, error) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	for _, doc := range ix.documents {
+		if doc.ID == id {
+			return doc, nil
+		}
+	}
+	return models.Document{}, ErrNotFound
+}
+
+func (ix *Index) Delete(id string) (models.Document, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	prevDocs, prevPassages := ix.documents, ix.passages
+	doc, ok := ix.removeLocked(id)
+	if !ok {
+		return models.Document{}, ErrNotFound
+	}
+	if err := ix.save(); err != nil {
+		ix.documents, ix.passages = prevDocs, prevPassages
+		return models.Document{}, err
+	}
+	ix.rebuild()
+	return doc, nil
+}
+
+// removeLocked drops a document and its passages without saving. The
+// slices are copied so a failed save can restore the previous ones.
+func (ix *Index) removeLocked(id string) (models.Document, bool) {
+	var removed models.Document
+	found := false
+	docs := make([]models.Document, 0, len(ix.documents))
+	for _, doc := range ix.documents {
+		if doc.ID == id {
+			removed, found = doc, true
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	if !found {
+		return models.Document{}, false
+	}
+
+	passages := make([]passage, 0, len(ix.passages))
+	for _, p := range ix.passages {
+		if p.DocumentID != id {
+			passages = append(passages, p)
+		}
+	}
+	ix.documents, ix.passages = docs, passages
+	return removed, true
+}
+
+// rebuild recomputes the term statistics BM25 needs. Callers hold the
+// write lock or own the index exclusively.
+func (ix *Index) rebuild() {
+	ix.docFreq = map[string]int{}
+	ix.totalLen = 0
+	for i := range ix.passages {
+		p := &ix.passages[i]
+		tokens := tokenize(p.Section + " " + p.Text)
+		p.terms = map[string]int{}
+		for _, t := range tokens {
+			p.terms[t]++
+		}
+		p.length = len(tokens)
+		ix.totalLen += p.length
+		for t := range p.terms {
+			ix.docFreq[t]++
+		}
+	}
+}
+
+func (ix *Index) save() error {
+	if ix.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(indexFile{Documents: ix.documents, Passages: ix.passages})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o755); err != nil {
+		return fmt.Errorf("writing document index: %w", err)
+	}
+
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing document index: %w", err)
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		return fmt.Errorf("writing document index: %w", err)
+	}
+	return nil
+}
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// rrfK damps the reciprocal rank fusion of keyword and vector results.
+	rrfK = 60
+)
+
+type scored struct {
+	index int
+	score float64
+}
+
+// Search returns up to limit passages ranked by relevance to query. With an
+// embedder, keyword and vector rankings are fused; if embedding the query
+// fails, keyword results are returned alone.
+func (ix *Index) Search(ctx context.Context, query string, limit int) []models.Passage {
+	ix.mu.RLock()
+	embedder := ix.embedder
+	ix.mu.RUnlock()
+
+	var queryVector []float32
+	if embedder != nil {
+		if vectors, err := embedder.Embed(ctx, []string{query}); err == nil && len(vectors) == 1 {
+			queryVector = vectors[0]
+		}
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	ranked := ix.keywordRank(query)
+	if queryVector != nil {
+		ranked = fuse(ranked, ix.vectorRank(queryVector))
+	}
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	titles := make(map[string]string, len(ix.documents))
+	for _, doc := range ix.documents {
+		titles[doc.ID] = doc.Title
+	}
+	out := make([]models.Passage, 0, len(ranked))
+	for _, r := range ranked {
+		p := ix.passages[r.index]
+		out = append(out, models.Passage{
+			DocumentID: p.DocumentID,
+			Title:      titles[p.DocumentID],
+			Section:    p.Section,
+			Page:       p.Page,
+			Text:       p.Text,
+			Score:      math.Round(r.score*1000) / 1000,
+		})
+	}
+	return out
+}
+
+func (ix *Index) keywordRank(query string) []scored {
+	if len(ix.passages) == 0 {
+		return nil
+	}
+
+	terms := map[string]bool{}
+	for _, t := range tokenize(query) {
+		terms[t] = true
+	}
+	n := float64(len(ix.passages))
+	avgLen := float64(ix.totalLen) / n
+
+	var ranked []scored
+	for i, p := range ix.passages {
+		score := 0.0
+		for t := range terms {
+			tf := float64(p.terms[t])
+			if tf == 0 {
+				continue
+			}
+			df := float64(ix.docFreq[t])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(p.length)/avgLen))
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{i, score})
+		}
+	}
+	sortScored(ranked)
+	return ranked
+}
+
+func (ix *Index) vectorRank(query []float32) []scored {
+	var ranked []scored
+	for i, p := range ix.passages {
+		if len(p.Vector) != len(query) {
+			continue
+		}
+		if score := cosine(p.Vector, query); score > 0 {
+			ranked = append(ranked, scored{i, score})
+		}
+	}
+	sortScored(ranked)
+	return ranked
+}
+
+// fuse combines rankings by reciprocal rank, which needs no calibration
+// between BM25 and cosine scores.
+func fuse(rankings ...[]scored) []scored {
+	scores := map[int]float64{}
+	for _, ranking := range rankings {
+		for rank, r := range ranking {
+			scores[r.index] += 1 / float64(rrfK+rank+1)
+		}
+	}
+	fused := make([]scored, 0, len(scores))
+	for i, s := range scores {
+		fused = append(fused, scored{i, s})
+	}
+	sortScored(fused)
+	return fused
+}
+
+func sortScored(s []scored) {
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].score != s[j].score {
+			return s[i].score > s[j].score
+		}
+		return s[i].index < s[j].index
+	})
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+var (
+	nonWord = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "in": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "was": true, "were": true,
+	"will": true, "with": true, "not": true, "no": true, "if": true, "then": true, "than": true,
+}
+
+// tokenize lowercases text, drops stopwords and folds simple plurals so
+// "valves" matches "valve".
+func tokenize(text string) []string {
+	fields := nonWord.Split(strings.ToLower(text), -1)
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f == "" || stopwords[f] {
+			continue
+		}
+		if len(f) > 3 && strings.HasSuffix(f, "s") && !strings.HasSuffix(f, "ss") {
+			f = f[:len(f)-1]
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+func slug(s string) string {
+	s = strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(s) > 48 {
+		s = strings.TrimRight(s[:48], "-")
+	}
+	if s == "" {
+		s = "document"
+	}
+	return s
+}
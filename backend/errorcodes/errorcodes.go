@@ -0,0 +1,342 @@
+// Package errorcodes stores vendor device error and alarm codes so their
+// documented meaning can be given to the model instead of a guess.
+package errorcodes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+var ErrNotFound = errors.New("error code not found")
+
+// Store is the code database's persistence boundary.
+type Store interface {
+	List() ([]models.ErrorCode, error)
+	Import(rows []models.ErrorCodeInput) (models.ImportResult, error)
+	Delete(id string) (models.ErrorCode, error)
+}
+
+// FileStore keeps the database in a JSON file, rewriting it on every change.
+// A missing file starts an empty database.
+type FileStore struct {
+	path  string
+	mu    sync.RWMutex
+	items []models.ErrorCode
+}
+
+// OpenFile loads the database at path. An empty path keeps it in memory
+// only.
+func OpenFile(path string) (*FileStore, error) {
+	s := &FileStore{path: path, items: []models.ErrorCode{}}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("reading error code database: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.items); err != nil {
+		return nil, fmt.Errorf("parsing error code database: %w", err)
+	}
+	return s, nil
+}
+
+func (s *FileStore) List() ([]models.ErrorCode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]models.ErrorCode, len(s.items))
+	copy(items, s.items)
+	return items, nil
+}
+
+// Import adds rows, replacing any entry with the same manufacturer, model
+// family and code.
+func (s *FileStore) Import(rows []models.ErrorCodeInput) (models.ImportResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := append([]models.ErrorCode{}, s.items...)
+	index := make(map[string]int, len(s.items))
+	for i, item := range s.items {
+		index[item.ID] = i
+	}
+
+	var result models.ImportResult
+	now := time.Now().UTC()
+	for _, row := range rows {
+		item := fromInput(row)
+		item.UpdatedAt = now
+		if i, ok := index[item.ID]; ok {
+			s.items[i] = item
+			result.Updated++
+			continue
+		}
+		index[item.ID] = len(s.items)
+		s.items = append(s.items, item)
+		result.Added++
+	}
+
+	if err := s.save(); err != nil {
+		s.items = previous
+		return models.ImportResult{}, err
+	}
+	return result, nil
+}
+
+func (s *FileStore) Delete(id string) (models.ErrorCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, item := range s.items {
+		if item.ID != id {
+			continue
+		}
+		previous := s.items
+		s.items = append(append([]models.ErrorCode{}, s.items[:i]...), s.items[i+1:]...)
+		if err := s.save(); err != nil {
+			s.items = previous
+			return models.ErrorCode{}, err
+		}
+		return item, nil
+	}
+	return models.ErrorCode{}, ErrNotFound
+}
+
+func (s *FileStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("writing error code database: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing error code database: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing error code database: %w", err)
+	}
+	return nil
+}
+
+func fromInput(in models.ErrorCodeInput) models.ErrorCode {
+	item := models.ErrorCode{
+		Manufacturer: strings.TrimSpace(in.Manufacturer),
+		ModelFamily:  strings.TrimSpace(in.ModelFamily),
+		Code:         strings.TrimSpace(in.Code),
+		Meaning:      strings.TrimSpace(in.Meaning),
+		Action:       strings.TrimSpace(in.Action),
+		Severity:     strings.TrimSpace(in.Severity),
+		Source:       strings.TrimSpace(in.Source),
+	}
+	item.ID = ID(item.Manufacturer, item.ModelFamily, item.Code)
+	return item
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ID derives the stable identifier of a manufacturer, model family and code.
+func ID(manufacturer, modelFamily, code string) string {
+	parts := []string{manufacturer, modelFamily, code}
+	for i, p := range parts {
+		parts[i] = strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(p), "-"), "-")
+	}
+	if parts[1] == "" {
+		parts = []string{parts[0], parts[2]}
+	}
+	return strings.Join(parts, "-")
+}
+
+// normalizeCode lets "AL.01", "al 01" and "AL-01" match each other.
+func normalizeCode(code string) string {
+	return nonSlug.ReplaceAllString(strings.ToLower(code), "")
+}
+
+// Query narrows a lookup. Manufacturers and Model are optional; when set,
+// entries from other manufacturers or model families are excluded.
+type Query struct {
+	Code          string
+	Manufacturers []string
+	Model         string
+}
+
+// Lookup returns the entries matching the code a technician reported. The
+// whole text is tried as one code and then word by word, so "AL.01 CAP.ERR"
+// finds AL.01.
+func Lookup(items []models.ErrorCode, q Query) []models.ErrorCode {
+	wanted := map[string]bool{}
+	if whole := normalizeCode(q.Code); whole != "" {
+		wanted[whole] = true
+	}
+	for _, word := range strings.FieldsFunc(q.Code, func(r rune) bool { return r == ' ' || r == ',' || r == ';' || r == '/' }) {
+		if n := normalizeCode(word); n != "" {
+			wanted[n] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	var out []models.ErrorCode
+	for _, item := range items {
+		if !wanted[normalizeCode(item.Code)] {
+			continue
+		}
+		if len(q.Manufacturers) > 0 && !matchesAny(item.Manufacturer, q.Manufacturers) {
+			continue
+		}
+		if q.Model != "" && item.ModelFamily != "" && !strings.HasPrefix(strings.ToUpper(q.Model), strings.ToUpper(item.ModelFamily)) {
+			continue
+		}
+		out = append(out, item)
+	}
+	sortItems(out)
+	return out
+}
+
+func matchesAny(manufacturer string, names []string) bool {
+	for _, name := range names {
+		if strings.EqualFold(strings.TrimSpace(name), manufacturer) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the entries of a manufacturer whose code, meaning or model
+// family contain query. Empty arguments match everything.
+func Filter(items []models.ErrorCode, manufacturer, query string) []models.ErrorCode {
+	query = strings.ToLower(strings.TrimSpace(query))
+	out := make([]models.ErrorCode, 0, len(items))
+	for _, item := range items {
+		if manufacturer != "" && !strings.EqualFold(item.Manufacturer, manufacturer) {
+			continue
+		}
+		if query != "" &&
+			!strings.Contains(strings.ToLower(item.Code), query) &&
+			!strings.Contains(strings.ToLower(item.ModelFamily), query) &&
+			!strings.Contains(strings.ToLower(item.Meaning), query) {
+			continue
+		}
+		out = append(out, item)
+	}
+	sortItems(out)
+	return out
+}
+
+func sortItems(items []models.ErrorCode) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Manufacturer != b.Manufacturer {
+			return a.Manufacturer < b.Manufacturer
+		}
+		if a.ModelFamily != b.ModelFamily {
+			return a.ModelFamily < b.ModelFamily
+		}
+		return a.Code < b.Code
+	})
+}
+
+// PromptContext renders matched codes as verified facts for the prompt,
+// labelled E1, E2 and so on so answers can cite them.
+func PromptContext(items []models.ErrorCode) string {
+	var b strings.Builder
+	for i, item := range items {
+		fmt.Fprintf(&b, "[E%d] %s code %s: %s\n", i+1, Device(item), item.Code, item.Meaning)
+		if item.Action != "" {
+			fmt.Fprintf(&b, "  Recommended action: %s\n", item.Action)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Device names the manufacturer and model family a code belongs to.
+func Device(item models.ErrorCode) string {
+	return strings.TrimSpace(item.Manufacturer + " " + item.ModelFamily)
+}
+
+var csvColumns = map[string]string{
+	"manufacturer": "manufacturer",
+	"modelfamily":  "modelFamily",
+	"code":         "code",
+	"meaning":      "meaning",
+	"action":       "action",
+	"severity":     "severity",
+	"source":       "source",
+}
+
+// ParseCSV reads rows with a header line naming the columns, in any order:
+// manufacturer, model_family, code, meaning, action, severity, source.
+func ParseCSV(r io.Reader) ([]models.ErrorCodeInput, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		key := strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		column, ok := csvColumns[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[i] = column
+	}
+
+	var rows []models.ErrorCodeInput
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var row models.ErrorCodeInput
+		for i, value := range record {
+			switch columns[i] {
+			case "manufacturer":
+				row.Manufacturer = value
+			case "modelFamily":
+				row.ModelFamily = value
+			case "code":
+				row.Code = value
+			case "meaning":
+				row.Meaning = value
+			case "action":
+				row.Action = value
+			case "severity":
+				row.Severity = value
+			case "source":
+				row.Source = value
+			}
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,177 @@
+// Package feedback checks technician feedback against the analysis it rates
+// and aggregates it into accuracy reports.
+package feedback
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// Sections lists, per analyzer, the result lists feedback can point into.
+var Sections = map[string][]string{
+	models.AnalysisTroubleshooting: {"causes", "steps", "safetyWarnings"},
+	models.AnalysisVCRA:            {"actions", "timeline"},
+	models.AnalysisSafety:          {"hazards", "mitigations", "standards"},
+	models.AnalysisCorrosion:       {"mechanisms", "recommendations"},
+}
+
+// CheckItems reports the refs that do not name an entry of result. field is
+// the request field the refs came from, for the error paths.
+func CheckItems(kind string, result json.RawMessage, field string, refs []models.ItemRef) []models.FieldError {
+	var lists map[string]json.RawMessage
+	json.Unmarshal(result, &lists)
+
+	var errs []models.FieldError
+	for i, ref := range refs {
+		path := fmt.Sprintf("%s[%d].section", field, i)
+		if !contains(Sections[kind], ref.Section) {
+			errs = append(errs, models.FieldError{
+				Field:   path,
+				Rule:    "oneof",
+				Message: fmt.Sprintf("section must be one of %s for a %s analysis", strings.Join(Sections[kind], ", "), kind),
+			})
+			continue
+		}
+
+		var items []json.RawMessage
+		json.Unmarshal(lists[ref.Section], &items)
+		if ref.Index >= len(items) {
+			errs = append(errs, models.FieldError{
+				Field:   fmt.Sprintf("%s[%d].index", field, i),
+				Rule:    "range",
+				Message: fmt.Sprintf("%s has %d items", ref.Section, len(items)),
+			})
+		}
+	}
+	return errs
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// PeriodStart returns the start of the day, ISO week or month containing t,
+// in UTC.
+func PeriodStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case IntervalWeek:
+		// Weeks start on Monday.
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// Report groups outcomes by analyzer and equipment and buckets each group
+// into periods. Groups are sorted by analyzer then equipment, periods
+// oldest first.
+func Report(outcomes []models.FeedbackOutcome, interval string) models.AccuracyReport {
+	type key struct{ kind, equipment string }
+	type group struct {
+		total   tally
+		periods map[time.Time]*tally
+	}
+
+	groups := map[key]*group{}
+	for _, o := range outcomes {
+		k := key{o.Type, o.Equipment}
+		g, ok := groups[k]
+		if !ok {
+			g = &group{periods: map[time.Time]*tally{}}
+			groups[k] = g
+		}
+		g.total.add(o)
+
+		start := PeriodStart(o.CreatedAt, interval)
+		p, ok := g.periods[start]
+		if !ok {
+			p = &tally{}
+			g.periods[start] = p
+		}
+		p.add(o)
+	}
+
+	report := models.AccuracyReport{Interval: interval, Groups: []models.AccuracyGroup{}}
+	for k, g := range groups {
+		out := models.AccuracyGroup{Type: k.kind, Equipment: k.equipment, Total: g.total.stats()}
+		for start, p := range g.periods {
+			out.Periods = append(out.Periods, models.AccuracyPeriod{Start: start, AccuracyStats: p.stats()})
+		}
+		sort.Slice(out.Periods, func(i, j int) bool { return out.Periods[i].Start.Before(out.Periods[j].Start) })
+		report.Groups = append(report.Groups, out)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Equipment < b.Equipment
+	})
+	return report
+}
+
+type tally struct {
+	feedback, ratingSum, accurate, helpful int
+	correct, incorrect                     int
+	timed, minutes                         int
+}
+
+func (t *tally) add(o models.FeedbackOutcome) {
+	t.feedback++
+	t.ratingSum += o.Rating
+	if o.Correct > 0 && o.Incorrect == 0 {
+		t.accurate++
+	}
+	if o.Rating >= 4 {
+		t.helpful++
+	}
+	t.correct += o.Correct
+	t.incorrect += o.Incorrect
+	if o.TimeToFixMinutes != nil {
+		t.timed++
+		t.minutes += *o.TimeToFixMinutes
+	}
+}
+
+func (t *tally) stats() models.AccuracyStats {
+	s := models.AccuracyStats{
+		Feedback:       t.feedback,
+		CorrectItems:   t.correct,
+		IncorrectItems: t.incorrect,
+	}
+	if t.feedback > 0 {
+		n := float64(t.feedback)
+		s.AverageRating = round(float64(t.ratingSum) / n)
+		s.AccuracyRate = round(float64(t.accurate) / n)
+		s.HelpfulRate = round(float64(t.helpful) / n)
+	}
+	if t.timed > 0 {
+		avg := round(float64(t.minutes) / float64(t.timed))
+		s.AverageTimeToFixMinutes = &avg
+	}
+	return s
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
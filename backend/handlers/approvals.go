@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/models"
+)
+
+// HandleApproveSafetyV1 records an engineer's sign-off of a safety
+// assessment. A later decision replaces an earlier one.
+func HandleApproveSafetyV1(c *gin.Context) {
+	var in models.ApprovalInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondInvalid(c, "Invalid approval", err)
+		return
+	}
+
+	rec, ok := loadAnalysis(c)
+	if !ok {
+		return
+	}
+	if rec.Type != models.AnalysisSafety || rec.Status != models.AnalysisSucceeded {
+		respondError(c, http.StatusConflict, models.ErrCodeConflict, "only completed safety assessments can be approved")
+		return
+	}
+
+	a := models.Approval{
+		AnalysisID: rec.ID,
+		Decision:   in.Decision,
+		Comment:    in.Comment,
+		User:       principal(c).Username,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := analysisStore().SaveApproval(c.Request.Context(), a); err != nil {
+		respondErr(c, err)
+		return
+	}
+
+	decision, _ := json.Marshal(a)
+	if err := appendAudit(c.Request.Context(), models.AuditEntry{
+		Kind:          models.AuditApproval,
+		AnalysisID:    rec.ID,
+		Type:          rec.Type,
+		User:          a.User,
+		Site:          rec.Site,
+		Model:         rec.Model,
+		PromptVersion: rec.PromptVersion,
+		Request:       rec.Result,
+		Response:      decision,
+	}); err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, a)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/audit"
+	"pcst-ai/backend/metrics"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/storage"
+)
+
+// auditKey keys the audit chain's HMAC. Without one the chain is plain
+// SHA-256.
+func auditKey() []byte {
+	return []byte(settings().Audit.HMACKey)
+}
+
+// errAuditUnavailable fails a request whose audit entry could not be
+// written: nothing may be answered or approved without a trace in the chain.
+var errAuditUnavailable = errors.New("the audit log could not be written; try again later")
+
+// appendAudit adds e to the audit chain. A failure is logged and counted,
+// and returned so the request fails.
+func appendAudit(ctx context.Context, e models.AuditEntry) error {
+	e.CreatedAt = time.Now().UTC()
+	if _, err := analysisStore().AppendAudit(context.WithoutCancel(ctx), e, auditKey()); err != nil {
+		slog.ErrorContext(ctx, "failed to append audit entry", "kind", e.Kind, "analysisId", e.AnalysisID, "error", err)
+		metrics.AuditFailures.WithLabelValues(e.Kind).Inc()
+		return newAPIError(http.StatusInternalServerError, models.ErrCodeInternal, errAuditUnavailable)
+	}
+	return nil
+}
+
+// auditAnalysis records what was asked and answered in an analysis.
+func auditAnalysis(ctx context.Context, rec models.AnalysisRecord) error {
+	response := rec.Result
+	if response == nil {
+		response = json.RawMessage("null")
+	}
+	return appendAudit(ctx, models.AuditEntry{
+		Kind:          models.AuditAnalysis,
+		AnalysisID:    rec.ID,
+		Type:          rec.Type,
+		User:          rec.User,
+		Site:          rec.Site,
+		Model:         rec.Model,
+		PromptVersion: rec.PromptVersion,
+		Request:       rec.Request,
+		Response:      response,
+		Error:         rec.Error,
+	})
+}
+
+// HandleVerifyAuditV1 walks the audit chain and reports whether any entry
+// was changed, removed or reordered.
+func HandleVerifyAuditV1(c *gin.Context) {
+	ctx := c.Request.Context()
+	result, err := audit.Verify(auditKey(), func(fn func(models.AuditEntry) error) error {
+		return analysisStore().ScanAudit(ctx, storage.AuditQuery{AllSites: true}, fn)
+	})
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, result)
+}
+
+// HandleExportAuditV1 downloads audit entries appended between from and to
+// as JSON lines (the default) or CSV. Callers limited to some sites get only
+// those sites' entries; such an export cannot be chain-verified on its own.
+func HandleExportAuditV1(c *gin.Context) {
+	p := principal(c)
+	q := storage.AuditQuery{Sites: p.Sites, AllSites: allSites(p)}
+	format := c.DefaultQuery("format", audit.FormatJSONL)
+
+	var fieldErrs []models.FieldError
+	invalid := func(field, rule, message string) {
+		fieldErrs = append(fieldErrs, models.FieldError{Field: field, Rule: rule, Message: message})
+	}
+	if format != audit.FormatJSONL && format != audit.FormatCSV {
+		invalid("format", "oneof", "format must be jsonl or csv")
+	}
+	var ok bool
+	if q.From, ok = parseHistoryTime(c.Query("from")); !ok {
+		invalid("from", "datetime", "from must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if q.To, ok = parseHistoryTime(c.Query("to")); !ok {
+		invalid("to", "datetime", "to must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if len(fieldErrs) > 0 {
+		respondInvalidFields(c, "Invalid export query", fieldErrs)
+		return
+	}
+
+	contentType := "application/x-ndjson"
+	if format == audit.FormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	name := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	c.Status(http.StatusOK)
+
+	ctx := c.Request.Context()
+	err := audit.Export(c.Writer, format, func(fn func(models.AuditEntry) error) error {
+		return analysisStore().ScanAudit(ctx, q, fn)
+	})
+	if err != nil {
+		// The status is already sent; all that is left is to cut the download short.
+		slog.ErrorContext(ctx, "audit export failed", "error", err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/auth"
+	"pcst-ai/backend/config"
+	"pcst-ai/backend/logging"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/sites"
+	"pcst-ai/backend/storage"
+)
+
+const principalKey = "principal"
+
+var (
+	authLocal *auth.Local
+	authChain auth.Chain
+)
+
+// anonymous is the caller of every request while authentication is
+// disabled.
+var anonymous = models.Principal{Subject: "anonymous", Username: "anonymous", Role: models.RoleAdmin, Sites: []string{}, Source: "none"}
+
+// SetAuth sets how requests are authenticated. local signs in local
+// accounts and is also part of chain. A nil chain disables authentication.
+func SetAuth(local *auth.Local, chain auth.Chain) {
+	authLocal = local
+	authChain = chain
+}
+
+// OpenAuth configures authentication from the auth settings:
+//
+//   - Disabled turns it off, for local development only.
+//   - TokenSecret signs local tokens; without it a random secret is used and
+//     tokens do not survive a restart. TokenTTL sets their lifetime.
+//   - OIDC accepts tokens from an external issuer.
+//   - AdminUsername and AdminPassword create the first admin when there are
+//     no local accounts.
+func OpenAuth(ctx context.Context) (*auth.Local, auth.Chain, error) {
+	cfg := settings().Auth
+	if cfg.Disabled {
+		slog.Warn("authentication is disabled; every request is treated as an admin")
+		return nil, nil, nil
+	}
+
+	secret := []byte(cfg.TokenSecret)
+	if len(secret) == 0 {
+		slog.Warn("AUTH_TOKEN_SECRET is not set; sign-ins will not survive a restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	local := auth.NewLocal(secret, time.Duration(cfg.TokenTTL), analysisStore())
+	chain := auth.Chain{local}
+
+	if cfg.OIDC.Issuer != "" {
+		oidc, err := auth.NewOIDC(ctx, auth.OIDCConfig{
+			Issuer:       cfg.OIDC.Issuer,
+			Audience:     cfg.OIDC.Audience,
+			RoleClaim:    cfg.OIDC.RoleClaim,
+			DefaultRole:  cfg.OIDC.DefaultRole,
+			SiteClaim:    cfg.OIDC.SiteClaim,
+			DefaultSites: oidcDefaultSites(cfg.OIDC),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		chain = append(chain, oidc)
+	}
+
+	if err := bootstrapAdmin(ctx, cfg.AdminUsername, cfg.AdminPassword); err != nil {
+		return nil, nil, err
+	}
+	return local, chain, nil
+}
+
+// oidcDefaultSites are the sites of tokens without a site claim. A
+// deployment without a sites file has only the default site, which they may
+// use unless configured otherwise.
+func oidcDefaultSites(cfg config.OIDC) []string {
+	if len(cfg.DefaultSites) > 0 || settings().Data.SitesFile != "" {
+		return cfg.DefaultSites
+	}
+	return []string{sites.DefaultID}
+}
+
+// bootstrapAdmin creates the first local admin.
+func bootstrapAdmin(ctx context.Context, username, password string) error {
+	store := analysisStore()
+	n, err := store.CountUsers(ctx)
+	if err != nil || n > 0 {
+		return err
+	}
+
+	if password == "" {
+		slog.Warn("no local accounts exist; set AUTH_ADMIN_PASSWORD to create an admin")
+		return nil
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	u := models.User{ID: newRecordID(), Username: username, Role: models.RoleAdmin, CreatedAt: now, UpdatedAt: now}
+	if err := store.CreateUser(ctx, u, hash); err != nil {
+		return err
+	}
+	slog.Info("created admin account", "username", username)
+	return nil
+}
+
+// Authenticate rejects requests without a valid bearer token and records
+// the caller for the handlers.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authChain == nil {
+			c.Set(principalKey, anonymous)
+			logging.FieldsFrom(c.Request.Context()).SetUser(anonymous.Username)
+			c.Next()
+			return
+		}
+
+		token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		p, err := authChain.Authenticate(c.Request.Context(), strings.TrimSpace(token))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="pcst-ai"`)
+			abortAuth(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, err.Error())
+			return
+		}
+		c.Set(principalKey, p)
+		logging.FieldsFrom(c.Request.Context()).SetUser(p.Username)
+		c.Next()
+	}
+}
+
+// Require lets through only callers whose role grants p.
+func Require(p auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.Allowed(principal(c).Role, p) {
+			abortAuth(c, http.StatusForbidden, models.ErrCodeForbidden, "your role does not allow this")
+			return
+		}
+		c.Next()
+	}
+}
+
+// abortAuth answers in the envelope on /api/v1 and in the legacy shape
+// elsewhere.
+func abortAuth(c *gin.Context, status int, code, message string) {
+	if strings.HasPrefix(c.FullPath(), "/api/v1/") {
+		respondError(c, status, code, message)
+		return
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": message})
+}
+
+// principal returns the caller recorded by Authenticate.
+func principal(c *gin.Context) models.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		return v.(models.Principal)
+	}
+	return models.Principal{}
+}
+
+// HandleLoginV1 signs in a local account and returns a bearer token.
+func HandleLoginV1(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalid(c, "Invalid sign-in", err)
+		return
+	}
+	if authLocal == nil {
+		respondError(c, http.StatusNotFound, models.ErrCodeNotFound, "local sign-in is not enabled")
+		return
+	}
+
+	u, hash, err := analysisStore().UserByUsername(c.Request.Context(), req.Username)
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		respondErr(c, err)
+		return
+	}
+	if err != nil {
+		hash = auth.DummyHash
+	}
+	// The password is checked even for missing or disabled accounts so every
+	// refusal takes as long.
+	if !auth.CheckPassword(hash, req.Password) || err != nil || u.Disabled {
+		respondError(c, http.StatusUnauthorized, models.ErrCodeUnauthorized, "invalid username or password")
+		return
+	}
+
+	token, expires, err := authLocal.Issue(u)
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, models.Session{Token: token, ExpiresAt: expires, User: auth.LocalPrincipal(u)})
+}
+
+func HandleMeV1(c *gin.Context) {
+	respond(c, http.StatusOK, principal(c))
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/services"
+)
+
+const citationInstruction = `
+CITATIONS:
+The labelled sources above ([D1], [K1], [E1], [L1] and so on) are verified. End every list item that relies on one with its labels in square brackets, e.g. "Check the impulse lines [D1]" or "Replace the capsule [E1, D2]". Leave an item unlabelled when it comes from your general knowledge. Never use a label that does not appear above.
+`
+
+// citationGuide tells the model how to cite the labelled sources in its
+// prompt. It is empty when there are none.
+func citationGuide(citations []models.Citation) string {
+	if len(citations) == 0 {
+		return ""
+	}
+	return citationInstruction
+}
+
+// attribute strips citation labels from a list of items and records which
+// sources each item cited. Labels that were not given to the model are
+// dropped, so an item citing only invented sources counts as
+// model-generated.
+func attribute(section string, items []string, citations []models.Citation) ([]string, []models.Attribution) {
+	known := make(map[string]bool, len(citations))
+	for _, c := range citations {
+		known[c.Ref] = true
+	}
+
+	clean := make([]string, len(items))
+	attributions := make([]models.Attribution, 0, len(items))
+	for i, item := range items {
+		text, labels := services.SplitCitations(item)
+		clean[i] = text
+
+		refs := []string{}
+		for _, label := range labels {
+			if known[label] {
+				refs = append(refs, label)
+			}
+		}
+		attributions = append(attributions, models.Attribution{
+			Section:        section,
+			Index:          i,
+			Refs:           refs,
+			ModelGenerated: len(refs) == 0,
+		})
+	}
+	return clean, attributions
+}
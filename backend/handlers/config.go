@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/config"
+)
+
+var serverConfig *config.Config
+
+// SetConfig sets the configuration the Open functions and handlers read.
+// Without it they use config.Default.
+func SetConfig(cfg *config.Config) {
+	serverConfig = cfg
+}
+
+func settings() *config.Config {
+	if serverConfig == nil {
+		return config.Default()
+	}
+	return serverConfig
+}
+
+// HandleConfigV1 shows the effective configuration with secrets redacted.
+func HandleConfigV1(c *gin.Context) {
+	respond(c, http.StatusOK, settings().Redacted())
+}
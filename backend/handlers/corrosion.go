@@ -8,31 +8,125 @@ import (
 	"github.com/gin-gonic/gin"
 	"pcst-ai/backend/models"
 	"pcst-ai/backend/services"
+	"pcst-ai/backend/units"
 )
 
+// corrosionInput is a CorrosionRequest normalised to canonical units.
+type corrosionInput struct {
+	Material    string
+	Temperature float64
+	PH          float64
+	Pressure    float64
+	Velocity    float64
+	System      units.System
+}
+
 func HandleCorrosion(c *gin.Context) {
 	var req models.CorrosionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "All process parameters are required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid process parameters", "details": bindingDetails(err)})
+		return
+	}
+
+	input, fieldErrs := normalizeCorrosion(req)
+	if len(fieldErrs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid process parameters", "details": fieldErrs})
+		return
+	}
+
+	details, err := recordAnalysis(c, models.AnalysisCorrosion, req, func(ctx context.Context) (models.CorrosionDetails, error) {
+		return analyzeCorrosion(ctx, input)
+	})
+	if err != nil {
+		respondLegacyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CorrosionResponse{
+		Success:  true,
+		Response: details,
+	})
+}
+
+func HandleCorrosionV1(c *gin.Context) {
+	var req models.CorrosionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalid(c, "Invalid process parameters", err)
+		return
+	}
+
+	input, fieldErrs := normalizeCorrosion(req)
+	if len(fieldErrs) > 0 {
+		respondInvalidFields(c, "Invalid process parameters", fieldErrs)
 		return
 	}
 
-	ctx := context.Background()
-	gemini, err := services.NewGeminiService(ctx)
+	details, err := recordAnalysis(c, models.AnalysisCorrosion, req, func(ctx context.Context) (models.CorrosionDetails, error) {
+		return analyzeCorrosion(ctx, input)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondErr(c, err)
 		return
 	}
-	defer gemini.Close()
 
-	prompt := fmt.Sprintf(`You are a Corrosion Engineering AI analyzing process equipment. Assess the corrosion risk based on the following parameters:
+	respond(c, http.StatusOK, details)
+}
+
+// normalizeCorrosion converts every quantity to its canonical unit and
+// checks it against the physical range accepted for that field.
+func normalizeCorrosion(req models.CorrosionRequest) (corrosionInput, []models.FieldError) {
+	var fieldErrs []models.FieldError
+	system, err := units.ParseSystem(req.UnitSystem)
+	if err != nil {
+		fieldErrs = append(fieldErrs, models.FieldError{Field: "unitSystem", Rule: "oneof", Message: err.Error()})
+	}
+
+	input := corrosionInput{
+		Material: req.Material,
+		PH:       *req.PH,
+		System:   system,
+	}
+	fields := []struct {
+		name      string
+		quantity  *units.Quantity
+		dimension units.Dimension
+		min, max  float64
+		target    *float64
+	}{
+		{"temperature", req.Temperature, units.Temperature, -273.15, 1200, &input.Temperature},
+		{"pressure", req.Pressure, units.Pressure, 0, 1000, &input.Pressure},
+		{"velocity", req.Velocity, units.Velocity, 0, 100, &input.Velocity},
+	}
+
+	for _, f := range fields {
+		value, err := f.quantity.Canonical(f.dimension)
+		if err != nil {
+			fieldErrs = append(fieldErrs, models.FieldError{Field: f.name, Rule: "unit", Message: f.name + ": " + err.Error()})
+			continue
+		}
+
+		unit := units.Canonical(f.dimension)
+		if value < f.min {
+			fieldErrs = append(fieldErrs, models.FieldError{Field: f.name, Rule: "gte", Message: fmt.Sprintf("%s must be at least %g %s", f.name, f.min, unit)})
+		} else if value > f.max {
+			fieldErrs = append(fieldErrs, models.FieldError{Field: f.name, Rule: "lte", Message: fmt.Sprintf("%s must be at most %g %s", f.name, f.max, unit)})
+		}
+		*f.target = value
+	}
+
+	return input, fieldErrs
+}
+
+// corrosionPrompt takes the material, the conditions in canonical units and
+// the reference sections.
+const corrosionPrompt = `You are a Corrosion Engineering AI analyzing process equipment. Assess the corrosion risk based on the following parameters:
 
 MATERIAL: %s
 OPERATING TEMPERATURE: %.1f°C
 pH LEVEL: %.1f
-OPERATING PRESSURE: %.1f bar
+OPERATING PRESSURE: %.1f bar(a)
 FLUID VELOCITY: %.1f m/s
-
+%s
 Provide your assessment in this exact format:
 
 CORROSION RISK:
@@ -54,25 +148,47 @@ RECOMMENDATIONS:
 ESTIMATED LIFE:
 [Equipment lifetime estimate]
 
-Base your analysis on industry standards, material properties, and process conditions. Be specific and technical.`,
-		req.Material, req.Temperature, req.PH, req.Pressure, req.Velocity)
+Base your analysis on industry standards, material properties, and process conditions. Be specific and technical.`
+
+func analyzeCorrosion(ctx context.Context, req corrosionInput) (models.CorrosionDetails, error) {
+	renderCtx, render := tracer.Start(ctx, "render prompt")
+	referenceText, citations := retrieve(renderCtx, req.Material+" corrosion mechanisms rate")
 
-	responseText, err := gemini.Generate(prompt)
+	prompt := fmt.Sprintf(corrosionPrompt,
+		req.Material, req.Temperature, req.PH, req.Pressure, req.Velocity, referenceText+citationGuide(citations))
+
+	render.End()
+	responseText, err := generate(ctx, prompt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return models.CorrosionDetails{}, err
 	}
 
-	riskLevel, corrosionRate, mechanisms, recommendations, estimatedLife := services.ParseCorrosionResponse(responseText)
-
-	c.JSON(http.StatusOK, models.CorrosionResponse{
-		Success: true,
-		Response: models.CorrosionDetails{
-			RiskLevel:       riskLevel,
-			CorrosionRate:   corrosionRate,
-			Mechanisms:      mechanisms,
-			Recommendations: recommendations,
-			EstimatedLife:   estimatedLife,
+	parseCtx, parse := tracer.Start(ctx, "parse answer")
+	defer parse.End()
+	riskLevel, corrosionRate, mechanisms, recommendations, estimatedLife, warnings := services.ParseCorrosionResponse(responseText)
+	noteParseWarnings(parseCtx, models.AnalysisCorrosion, warnings)
+	mechanisms, attributions := attribute("mechanisms", mechanisms, citations)
+	recommendations, recommendationSources := attribute("recommendations", recommendations, citations)
+	attributions = append(attributions, recommendationSources...)
+
+	// The model reports the rate in mm/y, the canonical unit.
+	rate := units.Render(corrosionRate, units.CorrosionRate, req.System)
+
+	return models.CorrosionDetails{
+		RiskLevel:         riskLevel,
+		CorrosionRate:     rate.Value,
+		CorrosionRateUnit: rate.Unit,
+		Mechanisms:        mechanisms,
+		Recommendations:   recommendations,
+		EstimatedLife:     services.StripCitations(estimatedLife),
+		UnitSystem:        string(req.System),
+		Conditions: models.ProcessConditions{
+			Temperature: units.Render(req.Temperature, units.Temperature, req.System),
+			PH:          req.PH,
+			Pressure:    units.Render(req.Pressure, units.Pressure, req.System),
+			Velocity:    units.Render(req.Velocity, units.Velocity, req.System),
 		},
-	})
+		Citations:    citations,
+		Attributions: attributions,
+	}, nil
 }
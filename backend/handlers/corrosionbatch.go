@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/metrics"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/units"
+)
+
+// maxCorrosionBatch bounds how many locations one batch may assess.
+const maxCorrosionBatch = 500
+
+// Batch result formats.
+const (
+	batchFormatJSON = "json"
+	batchFormatCSV  = "csv"
+)
+
+// HandleCorrosionBatchV1 assesses every location in a JSON array or CSV
+// body like a single corrosion analysis, each recorded in history, and
+// returns them ranked by risk. With format=csv the ranking is downloaded as
+// CSV instead.
+func HandleCorrosionBatchV1(c *gin.Context) {
+	format := c.DefaultQuery("format", batchFormatJSON)
+	if format != batchFormatJSON && format != batchFormatCSV {
+		respondInvalidFields(c, "Invalid batch", []models.FieldError{{Field: "format", Rule: "oneof", Message: "format must be json or csv"}})
+		return
+	}
+
+	rows, ok := bindRows(c, "Invalid batch", parseCorrosionCSV)
+	if !ok {
+		return
+	}
+	if len(rows) > maxCorrosionBatch {
+		respondInvalidFields(c, "Invalid batch", []models.FieldError{{Rule: "max", Message: fmt.Sprintf("a batch may hold at most %d locations", maxCorrosionBatch)}})
+		return
+	}
+
+	// Every row is checked before any is assessed, so a mistake on the last
+	// row does not cost the model calls for all the others.
+	inputs := make([]corrosionInput, len(rows))
+	var details []models.FieldError
+	seen := make(map[string]int, len(rows))
+	for i, row := range rows {
+		if first, ok := seen[row.ID]; ok {
+			details = append(details, models.FieldError{Field: fmt.Sprintf("[%d].id", i), Rule: "unique", Message: fmt.Sprintf("row %d: id %q repeats row %d", i+1, row.ID, first+1)})
+		}
+		seen[row.ID] = i
+
+		input, fieldErrs := normalizeCorrosion(row.Request())
+		for _, d := range fieldErrs {
+			d.Field = fmt.Sprintf("[%d].%s", i, d.Field)
+			d.Message = fmt.Sprintf("row %d: %s", i+1, d.Message)
+			details = append(details, d)
+		}
+		inputs[i] = input
+	}
+	if len(details) > 0 {
+		respondInvalidFields(c, "Invalid batch", details)
+		return
+	}
+
+	// A batch takes far longer than one analysis, so the server's write
+	// timeout would cut it off; the shutdown grace period still bounds it.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(c.Request.Context(), "batch keeps the server write timeout", "error", err)
+	}
+
+	batch := assessLocations(c, rows, inputs)
+	if format == batchFormatCSV {
+		name := fmt.Sprintf("corrosion-batch-%s.csv", time.Now().UTC().Format("20060102T150405Z"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+		c.Status(http.StatusOK)
+		if err := writeCorrosionBatchCSV(c.Writer, batch); err != nil {
+			slog.ErrorContext(c.Request.Context(), "corrosion batch download failed", "error", err)
+			c.Abort()
+		}
+		return
+	}
+	respond(c, http.StatusOK, batch)
+}
+
+// assessLocations runs the analyses at most Provider.BatchConcurrency at a
+// time and ranks the results. Locations not started before the request was
+// cancelled are reported as failed without being recorded.
+func assessLocations(c *gin.Context, rows []models.CorrosionLocation, inputs []corrosionInput) models.CorrosionBatch {
+	ctx := c.Request.Context()
+	items := make([]models.CorrosionBatchItem, len(rows))
+	slots := make(chan struct{}, settings().Provider.BatchConcurrency)
+	metrics.BatchQueueDepth.Add(float64(len(rows)))
+	var wg sync.WaitGroup
+	for i, row := range rows {
+		items[i] = models.CorrosionBatchItem{ID: row.ID, Description: row.Description, Material: row.Material, Status: models.AnalysisFailed}
+
+		select {
+		case slots <- struct{}{}:
+			metrics.BatchQueueDepth.Dec()
+		case <-ctx.Done():
+			metrics.BatchQueueDepth.Dec()
+			items[i].Error = "batch cancelled before this location was assessed"
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			details, err := recordAnalysis(c, models.AnalysisCorrosion, rows[i], func(ctx context.Context) (models.CorrosionDetails, error) {
+				return analyzeCorrosion(ctx, inputs[i])
+			})
+			if err != nil {
+				items[i].Error = err.Error()
+				return
+			}
+			items[i].Status = models.AnalysisSucceeded
+			items[i].Result = &details
+		}(i)
+	}
+	wg.Wait()
+
+	rankCorrosionBatch(items)
+	batch := models.CorrosionBatch{Total: len(items), Locations: items}
+	for _, item := range items {
+		if item.Status == models.AnalysisSucceeded {
+			batch.Succeeded++
+		} else {
+			batch.Failed++
+		}
+	}
+	return batch
+}
+
+var riskOrder = map[string]int{"HIGH": 3, "MEDIUM": 2, "LOW": 1}
+
+// rankCorrosionBatch orders items by risk level, then by corrosion rate,
+// keeping the submitted order for ties, and numbers them from 1.
+func rankCorrosionBatch(items []models.CorrosionBatchItem) {
+	// Rows may ask for different unit systems, so rates are compared in mm/y.
+	rate := func(item models.CorrosionBatchItem) float64 {
+		v, err := units.Convert(item.Result.CorrosionRate, item.Result.CorrosionRateUnit, "", units.CorrosionRate)
+		if err != nil {
+			return item.Result.CorrosionRate
+		}
+		return v
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if (a.Result == nil) != (b.Result == nil) {
+			return b.Result == nil
+		}
+		if a.Result == nil {
+			return false
+		}
+		if ra, rb := riskOrder[a.Result.RiskLevel], riskOrder[b.Result.RiskLevel]; ra != rb {
+			return ra > rb
+		}
+		return rate(a) > rate(b)
+	})
+	for i := range items {
+		items[i].Rank = i + 1
+	}
+}
+
+var corrosionBatchHeader = []string{
+	"rank", "id", "description", "material", "status", "riskLevel", "corrosionRate", "corrosionRateUnit",
+	"estimatedLife", "temperature", "temperatureUnit", "ph", "pressure", "pressureUnit", "velocity", "velocityUnit",
+	"mechanisms", "recommendations", "error",
+}
+
+// writeCorrosionBatchCSV writes one row per location in rank order, with
+// list fields joined by semicolons.
+func writeCorrosionBatchCSV(w io.Writer, batch models.CorrosionBatch) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(corrosionBatchHeader); err != nil {
+		return err
+	}
+	number := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, item := range batch.Locations {
+		record := []string{strconv.Itoa(item.Rank), item.ID, item.Description, item.Material, item.Status}
+		if r := item.Result; r != nil {
+			record = append(record,
+				r.RiskLevel, number(r.CorrosionRate), r.CorrosionRateUnit, r.EstimatedLife,
+				number(r.Conditions.Temperature.Value), r.Conditions.Temperature.Unit,
+				number(r.Conditions.PH),
+				number(r.Conditions.Pressure.Value), r.Conditions.Pressure.Unit,
+				number(r.Conditions.Velocity.Value), r.Conditions.Velocity.Unit,
+				strings.Join(r.Mechanisms, "; "), strings.Join(r.Recommendations, "; "),
+			)
+		} else {
+			record = append(record, make([]string, 13)...)
+		}
+		record = append(record, item.Error)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var corrosionCSVColumns = map[string]string{
+	"id":              "id",
+	"description":     "description",
+	"material":        "material",
+	"temperature":     "temperature",
+	"temperatureunit": "temperatureUnit",
+	"ph":              "ph",
+	"pressure":        "pressure",
+	"pressureunit":    "pressureUnit",
+	"velocity":        "velocity",
+	"velocityunit":    "velocityUnit",
+	"unitsystem":      "unitSystem",
+}
+
+// parseCorrosionCSV reads one location per row, with a header line naming
+// the columns in any order: id, description, material, temperature,
+// temperature_unit, ph, pressure, pressure_unit, velocity, velocity_unit,
+// unit_system. Quantities without a unit are in the canonical unit, as in
+// JSON; an empty cell leaves the field missing. Reading stops one row past
+// maxCorrosionBatch, which is enough to refuse the batch.
+func parseCorrosionCSV(r io.Reader) ([]models.CorrosionLocation, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		key := strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		column, ok := corrosionCSVColumns[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[i] = column
+	}
+
+	var rows []models.CorrosionLocation
+	for len(rows) <= maxCorrosionBatch {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var row models.CorrosionLocation
+		var temperature, pressure, velocity units.Quantity
+		var hasTemperature, hasPressure, hasVelocity bool
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			number := func(target *float64, present *bool) error {
+				if value == "" {
+					return nil
+				}
+				v, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return fmt.Errorf("row %d: %s %q is not a number", len(rows)+1, columns[i], value)
+				}
+				*target, *present = v, true
+				return nil
+			}
+
+			var err error
+			switch columns[i] {
+			case "id":
+				row.ID = value
+			case "description":
+				row.Description = value
+			case "material":
+				row.Material = value
+			case "temperature":
+				err = number(&temperature.Value, &hasTemperature)
+			case "temperatureUnit":
+				temperature.Unit = value
+			case "ph":
+				var ph float64
+				var hasPH bool
+				err = number(&ph, &hasPH)
+				if hasPH {
+					row.PH = &ph
+				}
+			case "pressure":
+				err = number(&pressure.Value, &hasPressure)
+			case "pressureUnit":
+				pressure.Unit = value
+			case "velocity":
+				err = number(&velocity.Value, &hasVelocity)
+			case "velocityUnit":
+				velocity.Unit = value
+			case "unitSystem":
+				row.UnitSystem = value
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if hasTemperature {
+			row.Temperature = &temperature
+		}
+		if hasPressure {
+			row.Pressure = &pressure
+		}
+		if hasVelocity {
+			row.Velocity = &velocity
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
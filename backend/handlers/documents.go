@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/docindex"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/services"
+)
+
+const maxDocumentBytes = 50 << 20
+
+var (
+	docIndex     *docindex.Index
+	docIndexOnce sync.Once
+)
+
+// SetDocumentIndex sets the index the analyzers retrieve passages from.
+func SetDocumentIndex(ix *docindex.Index) {
+	docIndex = ix
+}
+
+// OpenDocumentIndex opens the configured index file. A provider embedding
+// model (e.g. text-embedding-004) adds semantic search through the Gemini
+// API.
+func OpenDocumentIndex() (*docindex.Index, error) {
+	cfg := settings()
+	ix, err := docindex.Open(cfg.Data.DocumentIndexFile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Provider.EmbeddingModel != "" {
+		ix.SetEmbedder(services.GeminiEmbedder{APIKey: cfg.Provider.APIKey, Model: cfg.Provider.EmbeddingModel})
+	}
+	return ix, nil
+}
+
+func documentIndex() *docindex.Index {
+	docIndexOnce.Do(func() {
+		if docIndex == nil {
+			docIndex, _ = docindex.Open("")
+		}
+	})
+	return docIndex
+}
+
+func HandleListDocumentsV1(c *gin.Context) {
+	respond(c, http.StatusOK, siteDocuments(c.Request.Context()).List())
+}
+
+func HandleGetDocumentV1(c *gin.Context) {
+	doc, err := siteDocuments(c.Request.Context()).Get(c.Param("id"))
+	if err != nil {
+		respondDocumentErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, doc)
+}
+
+func HandleUploadDocumentV1(c *gin.Context) {
+	if !checkSharedWrite(c, "document index", ownDocuments) {
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDocumentBytes)
+
+	var details []models.FieldError
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, http.StatusRequestEntityTooLarge, models.ErrCodeInvalidRequest, fmt.Sprintf("document exceeds %d MB", maxDocumentBytes>>20))
+			return
+		}
+		details = append(details, models.FieldError{Field: "file", Rule: "required", Message: "file is required"})
+	}
+	kind := c.PostForm("kind")
+	switch kind {
+	case "", models.DocumentKindManual, models.DocumentKindSOP, models.DocumentKindBulletin, models.DocumentKindOther:
+	default:
+		details = append(details, models.FieldError{Field: "kind", Rule: "oneof", Message: "kind must be one of: manual sop bulletin other"})
+	}
+	title := c.PostForm("title")
+	if len(title) > 200 {
+		details = append(details, models.FieldError{Field: "title", Rule: "max", Message: "title must be at most 200 characters"})
+	}
+	description := c.PostForm("description")
+	if len(description) > 2000 {
+		details = append(details, models.FieldError{Field: "description", Rule: "max", Message: "description must be at most 2000 characters"})
+	}
+	if len(details) > 0 {
+		respondInvalidFields(c, "Invalid document upload", details)
+		return
+	}
+
+	format, err := docindex.DetectFormat(header.Filename, header.Header.Get("Content-Type"))
+	if err != nil {
+		respondInvalidFields(c, "Invalid document upload", []models.FieldError{{Field: "file", Rule: "format", Message: err.Error()}})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+
+	meta := docindex.Meta{Title: title, Kind: kind, Description: description}
+	doc, err := siteDocuments(c.Request.Context()).Ingest(c.Request.Context(), meta, header.Filename, format, data)
+	if errors.Is(err, docindex.ErrUnsupported) || errors.Is(err, docindex.ErrNoText) {
+		respondInvalidFields(c, "Invalid document upload", []models.FieldError{{Field: "file", Rule: "content", Message: err.Error()}})
+		return
+	}
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusCreated, doc)
+}
+
+func HandleDeleteDocumentV1(c *gin.Context) {
+	if !checkSharedWrite(c, "document index", ownDocuments) {
+		return
+	}
+	doc, err := siteDocuments(c.Request.Context()).Delete(c.Param("id"))
+	if err != nil {
+		respondDocumentErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, doc)
+}
+
+func HandleSearchDocumentsV1(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		respondInvalidFields(c, "Search text is required", []models.FieldError{{Field: "q", Rule: "required", Message: "q is required"}})
+		return
+	}
+	limit := 10
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 50 {
+			respondInvalidFields(c, "Invalid search", []models.FieldError{{Field: "limit", Rule: "range", Message: "limit must be a whole number from 1 to 50"}})
+			return
+		}
+		limit = n
+	}
+	respond(c, http.StatusOK, siteDocuments(c.Request.Context()).Search(c.Request.Context(), query, limit))
+}
+
+func respondDocumentErr(c *gin.Context, err error) {
+	if errors.Is(err, docindex.ErrNotFound) {
+		respondError(c, http.StatusNotFound, models.ErrCodeNotFound, err.Error())
+		return
+	}
+	respondErr(c, err)
+}
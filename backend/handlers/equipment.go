@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/catalog"
+	"pcst-ai/backend/models"
+)
+
+var (
+	equipmentCatalog     catalog.Store
+	equipmentCatalogOnce sync.Once
+)
+
+// SetEquipmentCatalog sets the catalog used by the equipment and
+// troubleshooting handlers for sites without a catalog of their own.
+func SetEquipmentCatalog(store catalog.Store) {
+	equipmentCatalog = store
+}
+
+// OpenEquipmentCatalog opens the configured catalog file.
+func OpenEquipmentCatalog() (catalog.Store, error) {
+	return catalog.OpenFile(settings().Data.EquipmentCatalogFile)
+}
+
+// equipmentStore falls back to the in-memory seed list when no catalog was
+// configured, so the handlers never see a nil store.
+func equipmentStore() catalog.Store {
+	equipmentCatalogOnce.Do(func() {
+		if equipmentCatalog == nil {
+			equipmentCatalog, _ = catalog.OpenFile("")
+		}
+	})
+	return equipmentCatalog
+}
+
+func HandleGetEquipment(c *gin.Context) {
+	items, err := siteCatalog(c.Request.Context()).List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	c.JSON(http.StatusOK, gin.H{"equipment": names})
+}
+
+func HandleListEquipmentV1(c *gin.Context) {
+	items, err := siteCatalog(c.Request.Context()).List()
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, catalog.Filter(items, c.Query("category"), c.Query("q")))
+}
+
+func HandleGetEquipmentV1(c *gin.Context) {
+	item, err := siteCatalog(c.Request.Context()).Get(c.Param("id"))
+	if err != nil {
+		respondCatalogErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, item)
+}
+
+func HandleCreateEquipmentV1(c *gin.Context) {
+	if !checkSharedWrite(c, "equipment catalog", ownCatalog) {
+		return
+	}
+	var in models.EquipmentInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondInvalid(c, "Invalid equipment", err)
+		return
+	}
+
+	item, err := siteCatalog(c.Request.Context()).Create(in)
+	if err != nil {
+		respondCatalogErr(c, err)
+		return
+	}
+	respond(c, http.StatusCreated, item)
+}
+
+func HandleUpdateEquipmentV1(c *gin.Context) {
+	if !checkSharedWrite(c, "equipment catalog", ownCatalog) {
+		return
+	}
+	var in models.EquipmentInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondInvalid(c, "Invalid equipment", err)
+		return
+	}
+
+	item, err := siteCatalog(c.Request.Context()).Update(c.Param("id"), in)
+	if err != nil {
+		respondCatalogErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, item)
+}
+
+func HandleDeleteEquipmentV1(c *gin.Context) {
+	if !checkSharedWrite(c, "equipment catalog", ownCatalog) {
+		return
+	}
+	item, err := siteCatalog(c.Request.Context()).Delete(c.Param("id"))
+	if err != nil {
+		respondCatalogErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, item)
+}
+
+func respondCatalogErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, catalog.ErrNotFound):
+		respondError(c, http.StatusNotFound, models.ErrCodeNotFound, err.Error())
+	case errors.Is(err, catalog.ErrConflict):
+		respondError(c, http.StatusConflict, models.ErrCodeConflict, err.Error())
+	case errors.Is(err, catalog.ErrInvalidID):
+		respondInvalidFields(c, "Invalid equipment", []models.FieldError{{Field: "id", Rule: "slug", Message: err.Error()}})
+	default:
+		respondErr(c, err)
+	}
+}
+
+func HandleGetKnowledgeV1(c *gin.Context) {
+	item, err := siteCatalog(c.Request.Context()).Get(c.Param("id"))
+	if err != nil {
+		respondCatalogErr(c, err)
+		return
+	}
+	if item.Knowledge.Empty() {
+		respondError(c, http.StatusNotFound, models.ErrCodeNotFound, "equipment has no knowledge pack")
+		return
+	}
+	respond(c, http.StatusOK, item.Knowledge)
+}
+
+func HandlePutKnowledgeV1(c *gin.Context) {
+	if !checkSharedWrite(c, "equipment catalog", ownCatalog) {
+		return
+	}
+	var pack models.KnowledgePack
+	if err := c.ShouldBindJSON(&pack); err != nil {
+		respondInvalid(c, "Invalid knowledge pack", err)
+		return
+	}
+
+	item, err := siteCatalog(c.Request.Context()).SetKnowledge(c.Param("id"), &pack)
+	if err != nil {
+		respondCatalogErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, item.Knowledge)
+}
+
+// HandleDeleteKnowledgeV1 clears the pack rather than removing it so the
+// built-in pack is not attached again on the next start. An empty pack is
+// treated as absent, so GET answers 404 afterwards.
+func HandleDeleteKnowledgeV1(c *gin.Context) {
+	if !checkSharedWrite(c, "equipment catalog", ownCatalog) {
+		return
+	}
+	item, err := siteCatalog(c.Request.Context()).SetKnowledge(c.Param("id"), &models.KnowledgePack{})
+	if err != nil {
+		respondCatalogErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, item.Knowledge)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/errorcodes"
+	"pcst-ai/backend/models"
+)
+
+var (
+	errorCodeDB     errorcodes.Store
+	errorCodeDBOnce sync.Once
+)
+
+// SetErrorCodeStore sets the vendor code database consulted by
+// troubleshooting.
+func SetErrorCodeStore(store errorcodes.Store) {
+	errorCodeDB = store
+}
+
+// OpenErrorCodeStore opens the configured error code file.
+func OpenErrorCodeStore() (errorcodes.Store, error) {
+	return errorcodes.OpenFile(settings().Data.ErrorCodesFile)
+}
+
+func errorCodeStore() errorcodes.Store {
+	errorCodeDBOnce.Do(func() {
+		if errorCodeDB == nil {
+			errorCodeDB, _ = errorcodes.OpenFile("")
+		}
+	})
+	return errorCodeDB
+}
+
+func HandleListErrorCodesV1(c *gin.Context) {
+	items, err := errorCodeStore().List()
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+
+	if code := c.Query("code"); code != "" {
+		query := errorcodes.Query{Code: code, Model: c.Query("model")}
+		if m := c.Query("manufacturer"); m != "" {
+			query.Manufacturers = []string{m}
+		}
+		respond(c, http.StatusOK, nonNilCodes(errorcodes.Lookup(items, query)))
+		return
+	}
+	respond(c, http.StatusOK, errorcodes.Filter(items, c.Query("manufacturer"), c.Query("q")))
+}
+
+func HandleImportErrorCodesV1(c *gin.Context) {
+	if !checkSharedWrite(c, "error code library", ownErrorCodes) {
+		return
+	}
+	rows, ok := bindRows(c, "Invalid import", errorcodes.ParseCSV)
+	if !ok {
+		return
+	}
+
+	result, err := errorCodeStore().Import(rows)
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, result)
+}
+
+func HandleDeleteErrorCodeV1(c *gin.Context) {
+	if !checkSharedWrite(c, "error code library", ownErrorCodes) {
+		return
+	}
+	item, err := errorCodeStore().Delete(c.Param("id"))
+	if errors.Is(err, errorcodes.ErrNotFound) {
+		respondError(c, http.StatusNotFound, models.ErrCodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, item)
+}
+
+func nonNilCodes(items []models.ErrorCode) []models.ErrorCode {
+	if items == nil {
+		return []models.ErrorCode{}
+	}
+	return items
+}
+
+// lookupErrorCodes finds the documented meaning of the code in a
+// troubleshooting request. Without an explicit manufacturer, only the
+// vendors listed for the equipment are considered.
+func lookupErrorCodes(req models.SearchRequest, equipment models.Equipment) ([]models.ErrorCode, error) {
+	if req.ErrorCode == "" {
+		return nil, nil
+	}
+	items, err := errorCodeStore().List()
+	if err != nil {
+		return nil, err
+	}
+
+	query := errorcodes.Query{Code: req.ErrorCode, Model: req.Model}
+	if req.Manufacturer != "" {
+		query.Manufacturers = []string{req.Manufacturer}
+	} else {
+		for _, v := range equipment.Vendors {
+			query.Manufacturers = append(query.Manufacturers, v.Name)
+		}
+	}
+	return errorcodes.Lookup(items, query), nil
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/feedback"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/storage"
+)
+
+// HandleCreateFeedbackV1 records a technician's rating and the real outcome
+// of an analysis. Item references are checked against the stored result.
+func HandleCreateFeedbackV1(c *gin.Context) {
+	var in models.FeedbackInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondInvalid(c, "Invalid feedback", err)
+		return
+	}
+
+	rec, ok := loadAnalysis(c)
+	if !ok {
+		return
+	}
+	if rec.Status != models.AnalysisSucceeded {
+		respondError(c, http.StatusConflict, models.ErrCodeConflict, "analysis failed and has no result to give feedback on")
+		return
+	}
+
+	fieldErrs := append(
+		feedback.CheckItems(rec.Type, rec.Result, "correct", in.Correct),
+		feedback.CheckItems(rec.Type, rec.Result, "incorrect", in.Incorrect)...,
+	)
+	for i, ref := range in.Incorrect {
+		for _, other := range in.Correct {
+			if ref == other {
+				fieldErrs = append(fieldErrs, models.FieldError{Field: fmt.Sprintf("incorrect[%d]", i), Rule: "excluded", Message: "an item cannot be both correct and incorrect"})
+			}
+		}
+	}
+	if len(fieldErrs) > 0 {
+		respondInvalidFields(c, "Invalid feedback", fieldErrs)
+		return
+	}
+
+	f := models.Feedback{
+		ID:               newRecordID(),
+		AnalysisID:       rec.ID,
+		User:             principal(c).Username,
+		Site:             rec.Site,
+		Rating:           in.Rating,
+		Correct:          nonNilRefs(in.Correct),
+		Incorrect:        nonNilRefs(in.Incorrect),
+		Resolution:       in.Resolution,
+		TimeToFixMinutes: in.TimeToFixMinutes,
+		Comment:          in.Comment,
+		CreatedAt:        time.Now().UTC(),
+	}
+	if err := analysisStore().SaveFeedback(c.Request.Context(), f); err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusCreated, f)
+}
+
+func HandleListFeedbackV1(c *gin.Context) {
+	rec, ok := loadAnalysis(c)
+	if !ok {
+		return
+	}
+	items, err := analysisStore().ListFeedback(c.Request.Context(), rec.ID)
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, items)
+}
+
+// HandleFeedbackReportV1 aggregates feedback into accuracy per analyzer and
+// equipment, bucketed by day, week or month.
+func HandleFeedbackReportV1(c *gin.Context) {
+	p := principal(c)
+	q := storage.OutcomeQuery{
+		Type:      c.Query("type"),
+		Equipment: c.Query("equipment"),
+		Site:      c.Query("site"),
+		Sites:     p.Sites,
+		AllSites:  allSites(p),
+	}
+	interval := c.DefaultQuery("interval", feedback.IntervalWeek)
+
+	var fieldErrs []models.FieldError
+	invalid := func(field, rule, message string) {
+		fieldErrs = append(fieldErrs, models.FieldError{Field: field, Rule: rule, Message: message})
+	}
+	if q.Type != "" && !analysisTypes[q.Type] {
+		invalid("type", "oneof", "type must be one of troubleshooting, vcra, safety, corrosion")
+	}
+	switch interval {
+	case feedback.IntervalDay, feedback.IntervalWeek, feedback.IntervalMonth:
+	default:
+		invalid("interval", "oneof", "interval must be day, week or month")
+	}
+	var ok bool
+	if q.From, ok = parseHistoryTime(c.Query("from")); !ok {
+		invalid("from", "datetime", "from must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if q.To, ok = parseHistoryTime(c.Query("to")); !ok {
+		invalid("to", "datetime", "to must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if len(fieldErrs) > 0 {
+		respondInvalidFields(c, "Invalid report query", fieldErrs)
+		return
+	}
+
+	outcomes, err := analysisStore().ListOutcomes(c.Request.Context(), q)
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, feedback.Report(outcomes, interval))
+}
+
+// loadAnalysis fetches the analysis named in the route, writing a 404 when
+// there is none or it belongs to a site the caller may not see.
+func loadAnalysis(c *gin.Context) (models.AnalysisRecord, bool) {
+	rec, err := analysisStore().GetAnalysis(c.Request.Context(), c.Param("id"))
+	if err == nil && !siteAllowed(principal(c), rec.Site) {
+		err = storage.ErrNotFound
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		respondError(c, http.StatusNotFound, models.ErrCodeNotFound, err.Error())
+		return rec, false
+	}
+	if err != nil {
+		respondErr(c, err)
+		return rec, false
+	}
+	return rec, true
+}
+
+func nonNilRefs(refs []models.ItemRef) []models.ItemRef {
+	if refs == nil {
+		return []models.ItemRef{}
+	}
+	return refs
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	attr "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"pcst-ai/backend/logging"
+	"pcst-ai/backend/metrics"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/services"
+)
+
+// generation captures what was sent to and received from the model during
+// one analysis, for the analysis record.
+type generation struct {
+	model  string
+	prompt string
+	output string
+}
+
+type generationKey struct{}
+
+func withGeneration(ctx context.Context) (context.Context, *generation) {
+	g := &generation{}
+	return context.WithValue(ctx, generationKey{}, g), g
+}
+
+var tracer = otel.Tracer("pcst-ai/backend/handlers")
+
+// generate sends a prompt to the model and returns its raw text. Each call
+// is logged with the request's ID, counted in its log fields, metered and
+// traced.
+func generate(ctx context.Context, prompt string) (string, error) {
+	provider := settings().Provider
+	ctx, span := tracer.Start(ctx, "provider generate",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attr.String("gen_ai.system", provider.Name),
+			attr.String("gen_ai.request.model", provider.Model),
+		),
+	)
+	defer span.End()
+	g, _ := ctx.Value(generationKey{}).(*generation)
+	if g != nil {
+		g.model = provider.Model
+		g.prompt = prompt
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(provider.Timeout))
+	defer cancel()
+	gemini, err := services.NewGeminiService(ctx, provider.APIKey, provider.Model)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return "", newAPIError(http.StatusServiceUnavailable, models.ErrCodeProviderUnavailable, err)
+	}
+	defer gemini.Close()
+
+	metrics.ProviderInFlight.Inc()
+	started := time.Now()
+	responseText, usage, err := gemini.Generate(prompt)
+	metrics.ProviderInFlight.Dec()
+	elapsed := time.Since(started)
+	metrics.ObserveLLM(provider.Model, elapsed, usage.PromptTokens, usage.OutputTokens, err)
+	span.SetAttributes(
+		attr.Int("gen_ai.usage.input_tokens", usage.PromptTokens),
+		attr.Int("gen_ai.usage.output_tokens", usage.OutputTokens),
+	)
+	logging.FieldsFrom(ctx).AddGeneration(provider.Model, usage.PromptTokens, usage.OutputTokens)
+	attrs := []slog.Attr{
+		slog.String("model", provider.Model),
+		slog.Int64("latencyMs", elapsed.Milliseconds()),
+		slog.Int("promptTokens", usage.PromptTokens),
+		slog.Int("outputTokens", usage.OutputTokens),
+	}
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "provider call failed", append(attrs, slog.String("error", err.Error()))...)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", newAPIError(http.StatusBadGateway, models.ErrCodeProviderError, err)
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "provider call", attrs...)
+	slog.LogAttrs(ctx, slog.LevelDebug, "provider exchange", logging.UserText("prompt", prompt), logging.UserText("output", responseText))
+	if g != nil {
+		g.output = responseText
+	}
+	return responseText, nil
+}
+
+// noteParseWarnings logs, counts and traces what the parser could not find
+// in an analyzer's answer.
+func noteParseWarnings(ctx context.Context, kind string, warnings []string) {
+	trace.SpanFromContext(ctx).SetAttributes(attr.Int("pcst.parse_warnings", len(warnings)))
+	logging.FieldsFrom(ctx).AddParseWarnings(warnings...)
+	metrics.ParseWarnings.WithLabelValues(kind).Add(float64(len(warnings)))
+}
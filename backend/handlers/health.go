@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/metrics"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/services"
+	"pcst-ai/backend/version"
+)
+
+// readinessTimeout bounds one round of readiness checks.
+const readinessTimeout = 5 * time.Second
+
+// errSkipped marks a check that does not apply to this configuration.
+var errSkipped = errors.New("skipped")
+
+type readinessCheck struct {
+	name string
+	run  func(ctx context.Context) (detail string, err error)
+}
+
+var readinessChecks = []readinessCheck{
+	{"provider", checkProvider},
+	{"database", checkDatabase},
+	{"documentIndex", checkDocumentIndex},
+}
+
+var (
+	readinessMu   sync.Mutex
+	lastReadiness models.Readiness
+)
+
+// HandleLive answers as long as the process serves requests. It checks no
+// dependency, so a failing provider does not get the server restarted.
+func HandleLive(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// HandleReady reports whether the server can take analyses, with 503 when a
+// dependency check failed so load balancers stop sending traffic.
+func HandleReady(c *gin.Context) {
+	r := readiness(c.Request.Context())
+	status := http.StatusOK
+	if r.Status != models.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, r)
+}
+
+// HandleVersionV1 identifies the build and the prompt versions it records.
+func HandleVersionV1(c *gin.Context) {
+	info := version.Info()
+	info.PromptVersions = promptVersions
+	respond(c, http.StatusOK, info)
+}
+
+// readiness runs every check, or returns the last results while they are
+// fresh. Concurrent probes wait for one round rather than each starting
+// their own.
+func readiness(ctx context.Context) models.Readiness {
+	readinessMu.Lock()
+	defer readinessMu.Unlock()
+	if !lastReadiness.CheckedAt.IsZero() && time.Since(lastReadiness.CheckedAt) < time.Duration(settings().Server.ReadinessCacheTTL) {
+		metrics.CacheHits.WithLabelValues("readiness").Inc()
+		return lastReadiness
+	}
+	metrics.CacheMisses.WithLabelValues("readiness").Inc()
+
+	// The results are shared, so a prober that gives up early must not
+	// cancel the checks and get a failure cached for everyone.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), readinessTimeout)
+	defer cancel()
+
+	r := models.Readiness{Status: models.Ready, Checks: make([]models.HealthCheck, len(readinessChecks))}
+	var wg sync.WaitGroup
+	for i, check := range readinessChecks {
+		wg.Add(1)
+		go func(i int, check readinessCheck) {
+			defer wg.Done()
+			started := time.Now()
+			detail, err := check.run(ctx)
+			result := models.HealthCheck{Name: check.name, Status: models.CheckOK, Detail: detail, LatencyMs: time.Since(started).Milliseconds()}
+			switch {
+			case errors.Is(err, errSkipped):
+				result.Status = models.CheckSkipped
+			case err != nil:
+				result.Status = models.CheckFailed
+				result.Detail = err.Error()
+			}
+			r.Checks[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, check := range r.Checks {
+		if check.Status == models.CheckFailed {
+			r.Status = models.NotReady
+			slog.WarnContext(ctx, "readiness check failed", "check", check.Name, "error", check.Detail)
+		}
+	}
+	r.CheckedAt = time.Now().UTC()
+	lastReadiness = r
+	return r
+}
+
+// checkProvider looks the configured model up, which needs a valid key and
+// a reachable provider but generates nothing.
+func checkProvider(ctx context.Context) (string, error) {
+	provider := settings().Provider
+	gemini, err := services.NewGeminiService(ctx, provider.APIKey, provider.Model)
+	if err != nil {
+		return "", err
+	}
+	defer gemini.Close()
+	if err := gemini.Ping(); err != nil {
+		return "", err
+	}
+	return provider.Name + " " + provider.Model, nil
+}
+
+func checkDatabase(ctx context.Context) (string, error) {
+	return "", analysisStore().Ping(ctx)
+}
+
+func checkDocumentIndex(ctx context.Context) (string, error) {
+	if !settings().Features.DocumentRetrieval {
+		return "document retrieval is turned off", errSkipped
+	}
+	ix := documentIndex()
+	if err := ix.Check(); err != nil {
+		return "", err
+	}
+	count := len(ix.List())
+	for _, s := range siteStore().List() {
+		if s.Documents == nil {
+			continue
+		}
+		if err := s.Documents.Check(); err != nil {
+			return "", fmt.Errorf("site %q: %w", s.ID, err)
+		}
+		count += len(s.Documents.List())
+	}
+	return fmt.Sprintf("%d documents", count), nil
+}
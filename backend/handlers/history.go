@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/storage"
+)
+
+var analysisTypes = map[string]bool{
+	models.AnalysisTroubleshooting: true,
+	models.AnalysisVCRA:            true,
+	models.AnalysisSafety:          true,
+	models.AnalysisCorrosion:       true,
+}
+
+// HandleListHistoryV1 lists and searches past analyses, newest first unless
+// sort and order say otherwise.
+func HandleListHistoryV1(c *gin.Context) {
+	q, fieldErrs := historyQuery(c)
+	if len(fieldErrs) > 0 {
+		respondInvalidFields(c, "Invalid history query", fieldErrs)
+		return
+	}
+
+	page, err := analysisStore().ListAnalyses(c.Request.Context(), q)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		respondInvalidFields(c, "Invalid history query", []models.FieldError{{Field: "cursor", Rule: "cursor", Message: err.Error()}})
+		return
+	}
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, page)
+}
+
+func HandleGetHistoryV1(c *gin.Context) {
+	rec, ok := loadAnalysis(c)
+	if !ok {
+		return
+	}
+	respond(c, http.StatusOK, rec)
+}
+
+func historyQuery(c *gin.Context) (storage.HistoryQuery, []models.FieldError) {
+	p := principal(c)
+	q := storage.HistoryQuery{
+		Type:      c.Query("type"),
+		Equipment: c.Query("equipment"),
+		RiskLevel: c.Query("risk"),
+		User:      c.Query("user"),
+		Site:      c.Query("site"),
+		Sites:     p.Sites,
+		AllSites:  allSites(p),
+		Text:      c.Query("q"),
+		Sort:      c.Query("sort"),
+		Cursor:    c.Query("cursor"),
+		Limit:     20,
+	}
+
+	var fieldErrs []models.FieldError
+	invalid := func(field, rule, message string) {
+		fieldErrs = append(fieldErrs, models.FieldError{Field: field, Rule: rule, Message: message})
+	}
+
+	if q.Type != "" && !analysisTypes[q.Type] {
+		invalid("type", "oneof", "type must be one of troubleshooting, vcra, safety, corrosion")
+	}
+	if q.Sort != "" {
+		if _, ok := storage.SortFields[q.Sort]; !ok {
+			invalid("sort", "oneof", "sort must be one of startedAt, completedAt, durationMs, type, equipment, riskLevel, user")
+		}
+	}
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		invalid("order", "oneof", "order must be asc or desc")
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			invalid("limit", "range", "limit must be a whole number from 1 to 100")
+		} else {
+			q.Limit = n
+		}
+	}
+
+	var ok bool
+	if q.From, ok = parseHistoryTime(c.Query("from")); !ok {
+		invalid("from", "datetime", "from must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if q.To, ok = parseHistoryTime(c.Query("to")); !ok {
+		invalid("to", "datetime", "to must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.To.After(q.From) {
+		invalid("to", "gtfield", "to must be after from")
+	}
+
+	return q, fieldErrs
+}
+
+// parseHistoryTime accepts an RFC 3339 time or a date, which means midnight
+// UTC. An empty value is the zero time.
+func parseHistoryTime(raw string) (time.Time, bool) {
+	if raw == "" {
+		return time.Time{}, true
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
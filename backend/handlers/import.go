@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"pcst-ai/backend/models"
+)
+
+// maxImportBytes bounds the body of an import or batch.
+const maxImportBytes = 10 << 20
+
+// bindRows reads an import body, either a JSON array or CSV when the
+// request is sent as text/csv, and validates every row. It responds with
+// message and returns false when the body is unusable.
+func bindRows[T any](c *gin.Context, message string, parseCSV func(io.Reader) ([]T, error)) ([]T, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var rows []T
+	var err error
+	if c.ContentType() == "text/csv" {
+		rows, err = parseCSV(c.Request.Body)
+	} else {
+		err = json.NewDecoder(c.Request.Body).Decode(&rows)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(c, http.StatusRequestEntityTooLarge, models.ErrCodeInvalidRequest, fmt.Sprintf("body exceeds %d MB", maxImportBytes>>20))
+		return nil, false
+	}
+	if err != nil {
+		respondInvalid(c, message, err)
+		return nil, false
+	}
+	if len(rows) == 0 {
+		respondInvalidFields(c, message, []models.FieldError{{Rule: "required", Message: "the body contains no rows"}})
+		return nil, false
+	}
+
+	var details []models.FieldError
+	for i := range rows {
+		if err := binding.Validator.ValidateStruct(&rows[i]); err != nil {
+			for _, d := range bindingDetails(err) {
+				d.Field = fmt.Sprintf("[%d].%s", i, d.Field)
+				d.Message = fmt.Sprintf("row %d: %s", i+1, d.Message)
+				details = append(details, d)
+			}
+		}
+	}
+	if len(details) > 0 {
+		respondInvalidFields(c, message, details)
+		return nil, false
+	}
+	return rows, true
+}
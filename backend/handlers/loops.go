@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/ild"
+	"pcst-ai/backend/models"
+)
+
+var (
+	loopRepo     ild.Store
+	loopRepoOnce sync.Once
+)
+
+// SetLoopStore sets the loop diagram repository used by troubleshooting.
+func SetLoopStore(store ild.Store) {
+	loopRepo = store
+}
+
+// OpenLoopStore opens the configured loop diagram file.
+func OpenLoopStore() (ild.Store, error) {
+	return ild.OpenFile(settings().Data.LoopDiagramsFile)
+}
+
+func loopStore() ild.Store {
+	loopRepoOnce.Do(func() {
+		if loopRepo == nil {
+			loopRepo, _ = ild.OpenFile("")
+		}
+	})
+	return loopRepo
+}
+
+func HandleListLoopsV1(c *gin.Context) {
+	loops, err := siteLoops(c.Request.Context()).List()
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, ild.Filter(loops, c.Query("q")))
+}
+
+func HandleGetLoopV1(c *gin.Context) {
+	loop, err := siteLoops(c.Request.Context()).Get(c.Param("tag"))
+	if err != nil {
+		respondLoopErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, loop)
+}
+
+func HandleImportLoopsV1(c *gin.Context) {
+	if !checkSharedWrite(c, "loop diagram repository", ownLoops) {
+		return
+	}
+	loops, ok := bindRows(c, "Invalid import", ild.ParseCSV)
+	if !ok {
+		return
+	}
+
+	result, err := siteLoops(c.Request.Context()).Import(loops)
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, result)
+}
+
+func HandleDeleteLoopV1(c *gin.Context) {
+	if !checkSharedWrite(c, "loop diagram repository", ownLoops) {
+		return
+	}
+	loop, err := siteLoops(c.Request.Context()).Delete(c.Param("tag"))
+	if err != nil {
+		respondLoopErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, loop)
+}
+
+func respondLoopErr(c *gin.Context, err error) {
+	if errors.Is(err, ild.ErrNotFound) {
+		respondError(c, http.StatusNotFound, models.ErrCodeNotFound, err.Error())
+		return
+	}
+	respondErr(c, err)
+}
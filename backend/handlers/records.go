@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/metrics"
+	"pcst-ai/backend/middleware"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/storage"
+)
+
+// Prompt versions are recorded with every analysis so results can be traced
+// to the prompt wording that produced them. Each is derived from its
+// template, so it changes whenever the wording does.
+var promptVersions = map[string]string{
+	models.AnalysisTroubleshooting: promptVersion(append([]string{troubleshootingPrompt}, troubleshootingGuidelines...)...),
+	models.AnalysisVCRA:            promptVersion(vcraPrompt),
+	models.AnalysisSafety:          promptVersion(safetyPrompt),
+	models.AnalysisCorrosion:       promptVersion(corrosionPrompt),
+}
+
+// promptVersion is the first 12 hex digits of the SHA-256 of a prompt's
+// fixed text.
+func promptVersion(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:6])
+}
+
+var analysisDB storage.Store
+
+// SetAnalysisStore sets where analysis records are written and history is
+// read from.
+func SetAnalysisStore(store storage.Store) {
+	analysisDB = store
+}
+
+// OpenAnalysisStore opens the configured database and applies any pending
+// migrations. Every call to it is traced.
+func OpenAnalysisStore() (storage.Store, error) {
+	db, err := storage.Open(context.Background(), settings().Storage.DSN)
+	if err != nil {
+		return nil, err
+	}
+	return storage.Traced(db, db.Dialect()), nil
+}
+
+// analysisStore is the store set at startup. There is no fallback: records
+// written anywhere but the configured database would be lost, so running
+// without one is a bug rather than something to recover from.
+func analysisStore() storage.Store {
+	if analysisDB == nil {
+		panic("handlers: analysis store used before SetAnalysisStore")
+	}
+	return analysisDB
+}
+
+// recordAnalysis runs an analyzer and stores its trace. A failure to store
+// the record is logged rather than failing the analysis, but a result that
+// could not be audited is not returned.
+func recordAnalysis[T any](c *gin.Context, kind string, req interface{}, analyze func(context.Context) (T, error)) (T, error) {
+	ctx, gen := withGeneration(c.Request.Context())
+	started := time.Now().UTC()
+	result, err := analyze(ctx)
+	completed := time.Now().UTC()
+
+	rec := models.AnalysisRecord{
+		AnalysisSummary: models.AnalysisSummary{
+			ID:            newRecordID(),
+			Type:          kind,
+			RequestID:     middleware.GetRequestID(c),
+			User:          principal(c).Username,
+			Site:          currentSite(c).ID,
+			Status:        models.AnalysisSucceeded,
+			Model:         gen.model,
+			PromptVersion: promptVersions[kind],
+			StartedAt:     started,
+			CompletedAt:   completed,
+			DurationMs:    completed.Sub(started).Milliseconds(),
+		},
+		Prompt:    gen.prompt,
+		RawOutput: gen.output,
+	}
+	rec.Request, _ = json.Marshal(req)
+	if err != nil {
+		rec.Status = models.AnalysisFailed
+		rec.Error = err.Error()
+	} else {
+		rec.Result, _ = json.Marshal(result)
+	}
+	rec.Equipment, rec.RiskLevel = summarize(req, result)
+	if err == nil && rec.RiskLevel != "" {
+		metrics.RiskLevels.WithLabelValues(kind, rec.RiskLevel).Inc()
+	}
+
+	// The request may already be cancelled; the record should still be kept.
+	if saveErr := analysisStore().SaveAnalysis(context.WithoutCancel(ctx), rec); saveErr != nil {
+		slog.ErrorContext(ctx, "failed to record analysis", "type", kind, "analysisId", rec.ID, "error", saveErr)
+	}
+	if auditErr := auditAnalysis(ctx, rec); auditErr != nil && err == nil {
+		var zero T
+		return zero, auditErr
+	}
+	return result, err
+}
+
+// summarize picks out the fields history is filtered on. Equipment comes
+// from the request, so failed runs can be found too, and is replaced by the
+// catalog ID troubleshooting resolved it to. Corrosion is filed under the
+// material assessed.
+func summarize(req, result interface{}) (equipment, riskLevel string) {
+	switch r := req.(type) {
+	case models.SearchRequest:
+		equipment = r.Equipment
+	case models.CorrosionRequest:
+		equipment = r.Material
+	case models.CorrosionLocation:
+		equipment = r.Material
+	}
+
+	switch r := result.(type) {
+	case models.TroubleshootingDetails:
+		if r.EquipmentID != "" {
+			equipment = r.EquipmentID
+		}
+	case models.VCRADetails:
+		riskLevel = r.RiskLevel
+	case models.SafetyDetails:
+		riskLevel = r.HazardLevel
+	case models.CorrosionDetails:
+		riskLevel = r.RiskLevel
+	}
+	return equipment, riskLevel
+}
+
+func newRecordID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
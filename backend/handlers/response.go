@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/middleware"
+	"pcst-ai/backend/models"
+)
+
+const APIVersion = "v1"
+
+// apiError is returned by the analyzers so the versioned routes can map a
+// failure onto a status and error code. Error() keeps the plain message the
+// legacy routes have always returned.
+type apiError struct {
+	status  int
+	code    string
+	message string
+	details []models.FieldError
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func newAPIError(status int, code string, err error) *apiError {
+	return &apiError{status: status, code: code, message: err.Error()}
+}
+
+func respond(c *gin.Context, status int, data interface{}) {
+	c.JSON(status, models.Envelope{
+		Success:   true,
+		Data:      data,
+		RequestID: middleware.GetRequestID(c),
+		Metadata:  newMetadata(),
+	})
+}
+
+func respondError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, models.Envelope{
+		Success: false,
+		Error: &models.APIError{
+			Code:    code,
+			Message: message,
+		},
+		RequestID: middleware.GetRequestID(c),
+		Metadata:  newMetadata(),
+	})
+}
+
+func respondErr(c *gin.Context, err error) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		if len(apiErr.details) > 0 {
+			respondInvalidFields(c, apiErr.message, apiErr.details)
+			return
+		}
+		respondError(c, apiErr.status, apiErr.code, apiErr.message)
+		return
+	}
+	respondError(c, http.StatusInternalServerError, models.ErrCodeInternal, err.Error())
+}
+
+// respondLegacyErr writes an error in the unversioned routes' shape. Only
+// client errors keep their status; everything else stays a 500 as it
+// always has.
+func respondLegacyErr(c *gin.Context, err error) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.status < http.StatusInternalServerError {
+		body := gin.H{"error": apiErr.message}
+		if len(apiErr.details) > 0 {
+			body["details"] = apiErr.details
+		}
+		c.JSON(apiErr.status, body)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func newMetadata() models.Metadata {
+	return models.Metadata{
+		APIVersion: APIVersion,
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+// Deprecated marks a legacy route as superseded by its /api/v1 equivalent.
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successor+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
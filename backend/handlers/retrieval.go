@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	attr "go.opentelemetry.io/otel/attribute"
+	"pcst-ai/backend/models"
+)
+
+const (
+	retrievalLimit       = 4
+	maxPromptPassageSize = 1200
+	maxExcerptSize       = 300
+)
+
+// retrieve finds the document passages relevant to a request. It returns
+// them as a prompt section, labelled D1, D2 and so on, and as citations in
+// the same order. Both are empty when nothing matches or retrieval is
+// switched off.
+func retrieve(ctx context.Context, query string) (string, []models.Citation) {
+	if !settings().Features.DocumentRetrieval {
+		return "", []models.Citation{}
+	}
+	ctx, span := tracer.Start(ctx, "retrieve documents")
+	defer span.End()
+	passages := siteDocuments(ctx).Search(ctx, query, retrievalLimit)
+	span.SetAttributes(attr.Int("pcst.passages", len(passages)))
+	if len(passages) == 0 {
+		return "", []models.Citation{}
+	}
+
+	var b strings.Builder
+	b.WriteString("\nREFERENCE DOCUMENTS (excerpts from plant manuals and procedures; prefer them over general knowledge where they apply):\n")
+	citations := make([]models.Citation, 0, len(passages))
+	for i, p := range passages {
+		ref := fmt.Sprintf("D%d", i+1)
+		location := passageLocation(p)
+		citations = append(citations, models.Citation{
+			Ref:      ref,
+			Type:     models.CitationTypeDocument,
+			SourceID: p.DocumentID,
+			Title:    p.Title,
+			Location: location,
+			Excerpt:  truncate(p.Text, maxExcerptSize),
+		})
+
+		fmt.Fprintf(&b, "[%s] %s", ref, p.Title)
+		if location != "" {
+			fmt.Fprintf(&b, " (%s)", location)
+		}
+		fmt.Fprintf(&b, ":\n%s\n\n", truncate(p.Text, maxPromptPassageSize))
+	}
+	return b.String(), citations
+}
+
+func passageLocation(p models.Passage) string {
+	var parts []string
+	if p.Section != "" {
+		parts = append(parts, p.Section)
+	}
+	if p.Page > 0 {
+		parts = append(parts, fmt.Sprintf("page %d", p.Page))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// truncate shortens text to at most n bytes on a word boundary.
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	cut := strings.LastIndex(text[:n], " ")
+	if cut <= 0 {
+		cut = n
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	return strings.TrimSpace(text[:cut]) + "…"
+}
@@ -17,28 +17,54 @@ func HandleSafety(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	gemini, err := services.NewGeminiService(ctx)
+	details, err := recordAnalysis(c, models.AnalysisSafety, req, func(ctx context.Context) (models.SafetyDetails, error) {
+		return analyzeSafety(ctx, req)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondLegacyErr(c, err)
 		return
 	}
-	defer gemini.Close()
 
-	prompt := fmt.Sprintf(`You are an AI Safety Advisor for oil and gas operations. Analyze the following job task and provide a comprehensive safety assessment.
+	c.JSON(http.StatusOK, models.SafetyResponse{
+		Success:  true,
+		Response: details,
+	})
+}
 
-JOB TASK:
-%s
+func HandleSafetyV1(c *gin.Context) {
+	var req models.SafetyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalid(c, "Task description is required", err)
+		return
+	}
+
+	details, err := recordAnalysis(c, models.AnalysisSafety, req, func(ctx context.Context) (models.SafetyDetails, error) {
+		return analyzeSafety(ctx, req)
+	})
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, details)
+}
+
+// safetyPrompt takes, by index, the task, the severity and likelihood
+// levels, the risk scale, the reference sections and the standards clause.
+const safetyPrompt = `You are an AI Safety Advisor for oil and gas operations. Analyze the following job task and provide a comprehensive safety assessment.
 
+JOB TASK:
+%[1]s
+%[5]s
 Provide your assessment in this exact format:
 
 HAZARD LEVEL:
 [HIGH/MEDIUM/LOW]
 
 IDENTIFIED HAZARDS:
-- [Hazard 1]
-- [Hazard 2]
-- [Hazard 3]
+- [Hazard 1] | SEVERITY: [1-%[2]d] | LIKELIHOOD: [1-%[3]d] | RESIDUAL SEVERITY: [1-%[2]d] | RESIDUAL LIKELIHOOD: [1-%[3]d]
+- [Hazard 2] | SEVERITY: [1-%[2]d] | LIKELIHOOD: [1-%[3]d] | RESIDUAL SEVERITY: [1-%[2]d] | RESIDUAL LIKELIHOOD: [1-%[3]d]
+- [Hazard 3] | SEVERITY: [1-%[2]d] | LIKELIHOOD: [1-%[3]d] | RESIDUAL SEVERITY: [1-%[2]d] | RESIDUAL LIKELIHOOD: [1-%[3]d]
 
 RECOMMENDED MITIGATIONS:
 - [Mitigation 1]
@@ -50,32 +76,95 @@ RELEVANT STANDARDS:
 - [Standard 2]
 - [Standard 3]
 
-Be thorough and specific. Include industry best practices and Aramco safety standards.`, req.Task)
+Rate every hazard on the scales below. SEVERITY and LIKELIHOOD describe the hazard before any mitigation; RESIDUAL SEVERITY and RESIDUAL LIKELIHOOD describe it after the recommended mitigations are in place. Use whole numbers only.
+
+%[4]s
+
+Be thorough and specific. Include industry best practices%[6]s.`
 
-	responseText, err := gemini.Generate(prompt)
+func analyzeSafety(ctx context.Context, req models.SafetyRequest) (models.SafetyDetails, error) {
+	matrix, err := siteRiskMatrix(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return models.SafetyDetails{}, newAPIError(http.StatusInternalServerError, models.ErrCodeInternal, err)
 	}
 
-	hazardLevel, hazards, mitigations, standards := services.ParseSafetyResponse(responseText)
+	renderCtx, render := tracer.Start(ctx, "render prompt")
+	referenceText, citations := retrieve(renderCtx, req.Task)
+
+	prompt := fmt.Sprintf(safetyPrompt, req.Task, matrix.SeverityLevels(), matrix.LikelihoodLevels(), matrix.PromptScale(), referenceText+citationGuide(citations), standardsClause(siteFrom(ctx)))
 
+	render.End()
+	responseText, err := generate(ctx, prompt)
+	if err != nil {
+		return models.SafetyDetails{}, err
+	}
+
+	parseCtx, parse := tracer.Start(ctx, "parse answer")
+	defer parse.End()
+	hazardLevel, hazards, mitigations, standards, warnings := services.ParseSafetyResponse(responseText)
+	noteParseWarnings(parseCtx, models.AnalysisSafety, warnings)
+	mitigations, attributions := attribute("mitigations", mitigations, citations)
+	standards, standardSources := attribute("standards", standards, citations)
+	attributions = append(attributions, standardSources...)
+
+	// The overall levels are the worst rated hazard; the model's own
+	// HAZARD LEVEL is only used when no hazard could be rated.
+	initialLevel := "UNRATED"
+	residualLevel := "UNRATED"
 	hazardDetails := make([]models.HazardDetail, 0, len(hazards))
 	for _, h := range hazards {
-		hazardDetails = append(hazardDetails, models.HazardDetail{
-			Name:        h["name"],
-			Severity:    h["severity"],
-			Probability: h["probability"],
-		})
+		detail := rateHazard(matrix, h)
+		hazardDetails = append(hazardDetails, detail)
+
+		if detail.InitialRisk > 0 && matrix.Rank(detail.InitialRiskLevel) > matrix.Rank(initialLevel) {
+			initialLevel = detail.InitialRiskLevel
+		}
+		if detail.ResidualRisk > 0 && matrix.Rank(detail.ResidualRiskLevel) > matrix.Rank(residualLevel) {
+			residualLevel = detail.ResidualRiskLevel
+		}
+	}
+	if initialLevel != "UNRATED" {
+		hazardLevel = initialLevel
 	}
 
-	c.JSON(http.StatusOK, models.SafetyResponse{
-		Success: true,
-		Response: models.SafetyDetails{
-			HazardLevel: hazardLevel,
-			Hazards:     hazardDetails,
-			Mitigations: mitigations,
-			Standards:   standards,
-		},
-	})
+	return models.SafetyDetails{
+		HazardLevel:       hazardLevel,
+		ResidualRiskLevel: residualLevel,
+		RiskMatrix:        matrix.Name,
+		Hazards:           hazardDetails,
+		Mitigations:       mitigations,
+		Standards:         standards,
+		Citations:         citations,
+		Attributions:      attributions,
+	}, nil
+}
+
+// rateHazard places a hazard's ratings on the risk matrix. When the model
+// omits residual ratings the hazard is assumed to be unmitigated.
+func rateHazard(matrix *services.RiskMatrix, h services.HazardRating) models.HazardDetail {
+	residualSeverity := h.ResidualSeverity
+	if residualSeverity == 0 {
+		residualSeverity = h.Severity
+	}
+	residualLikelihood := h.ResidualLikelihood
+	if residualLikelihood == 0 {
+		residualLikelihood = h.Likelihood
+	}
+
+	initial := matrix.Score(h.Severity, h.Likelihood)
+	residual := matrix.Score(residualSeverity, residualLikelihood)
+
+	return models.HazardDetail{
+		Name:               h.Name,
+		Severity:           matrix.SeverityLabel(h.Severity),
+		Probability:        matrix.LikelihoodLabel(h.Likelihood),
+		SeverityRating:     h.Severity,
+		LikelihoodRating:   h.Likelihood,
+		InitialRisk:        initial,
+		InitialRiskLevel:   matrix.Level(initial),
+		ResidualSeverity:   residualSeverity,
+		ResidualLikelihood: residualLikelihood,
+		ResidualRisk:       residual,
+		ResidualRiskLevel:  matrix.Level(residual),
+	}
 }
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/catalog"
+	"pcst-ai/backend/docindex"
+	"pcst-ai/backend/ild"
+	"pcst-ai/backend/logging"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/services"
+	"pcst-ai/backend/sites"
+)
+
+// SiteHeader names the site a request is for. Without it the caller's first
+// site is used, or the default site for callers granted every site.
+const SiteHeader = "X-Site"
+
+var (
+	siteRegistry     *sites.Registry
+	siteRegistryOnce sync.Once
+)
+
+func SetSites(r *sites.Registry) {
+	siteRegistry = r
+}
+
+// OpenSites loads the configured sites file. Without one there is a single
+// site, "default". Site document indexes search semantically like the shared
+// one when the provider has an embedding model.
+func OpenSites() (*sites.Registry, error) {
+	cfg := settings()
+	r, err := sites.Load(cfg.Data.SitesFile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Provider.EmbeddingModel != "" {
+		for _, s := range r.List() {
+			if s.Documents != nil {
+				s.Documents.SetEmbedder(services.GeminiEmbedder{APIKey: cfg.Provider.APIKey, Model: cfg.Provider.EmbeddingModel})
+			}
+		}
+	}
+	return r, nil
+}
+
+func siteStore() *sites.Registry {
+	siteRegistryOnce.Do(func() {
+		if siteRegistry == nil {
+			siteRegistry, _ = sites.Load("")
+		}
+	})
+	return siteRegistry
+}
+
+type siteKey struct{}
+
+// ResolveSite picks the site of the request and refuses callers who may not
+// use it. It must run after Authenticate.
+func ResolveSite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := principal(c)
+		id := c.GetHeader(SiteHeader)
+		if id == "" && len(p.Sites) > 0 && p.Sites[0] != models.AllSites {
+			id = p.Sites[0]
+		}
+
+		s := siteStore().Default()
+		if id != "" {
+			var ok bool
+			if s, ok = siteStore().Get(id); !ok {
+				abortAuth(c, http.StatusBadRequest, models.ErrCodeInvalidRequest, fmt.Sprintf("unknown site %q", id))
+				return
+			}
+		}
+		if !siteAllowed(p, s.ID) {
+			abortAuth(c, http.StatusForbidden, models.ErrCodeForbidden, fmt.Sprintf("you do not have access to site %q", s.ID))
+			return
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), siteKey{}, s))
+		logging.FieldsFrom(c.Request.Context()).SetSite(s.ID)
+		c.Next()
+	}
+}
+
+// siteFrom returns the site ResolveSite chose, or the default site.
+func siteFrom(ctx context.Context) *sites.Site {
+	if s, ok := ctx.Value(siteKey{}).(*sites.Site); ok {
+		return s
+	}
+	return siteStore().Default()
+}
+
+func currentSite(c *gin.Context) *sites.Site {
+	return siteFrom(c.Request.Context())
+}
+
+// siteAllowed reports whether p may see data of site id. A caller granted
+// no sites may see none.
+func siteAllowed(p models.Principal, id string) bool {
+	if allSites(p) {
+		return true
+	}
+	for _, s := range p.Sites {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
+
+// allSites reports whether p may see every site: admins, and callers
+// granted AllSites.
+func allSites(p models.Principal) bool {
+	if p.Role == models.RoleAdmin {
+		return true
+	}
+	for _, s := range p.Sites {
+		if s == models.AllSites {
+			return true
+		}
+	}
+	return false
+}
+
+// siteCatalog is the equipment catalog of the request's site.
+func siteCatalog(ctx context.Context) catalog.Store {
+	if s := siteFrom(ctx); s.Catalog != nil {
+		return s.Catalog
+	}
+	return equipmentStore()
+}
+
+// siteLoops is the loop diagram repository of the request's site.
+func siteLoops(ctx context.Context) ild.Store {
+	if s := siteFrom(ctx); s.Loops != nil {
+		return s.Loops
+	}
+	return loopStore()
+}
+
+// siteDocuments is the document index of the request's site.
+func siteDocuments(ctx context.Context) *docindex.Index {
+	if s := siteFrom(ctx); s.Documents != nil {
+		return s.Documents
+	}
+	return documentIndex()
+}
+
+// Whether a site keeps its own copy of reference data rather than sharing
+// the global one.
+func ownCatalog(s *sites.Site) bool   { return s.Catalog != nil }
+func ownLoops(s *sites.Site) bool     { return s.Loops != nil }
+func ownDocuments(s *sites.Site) bool { return s.Documents != nil }
+func ownErrorCodes(*sites.Site) bool  { return false }
+
+// checkSharedWrite refuses with 403 a change to data the request's site
+// shares with others, unless the caller may use every site sharing it; an
+// engineer of one plant must not change another plant's reference data.
+// It reports whether the change may go ahead.
+func checkSharedWrite(c *gin.Context, what string, own func(*sites.Site) bool) bool {
+	p := principal(c)
+	if own(currentSite(c)) || allSites(p) {
+		return true
+	}
+	for _, s := range siteStore().List() {
+		if !own(s) && !siteAllowed(p, s.ID) {
+			respondError(c, http.StatusForbidden, models.ErrCodeForbidden, fmt.Sprintf("the %s is shared with site %q, which you do not have access to", what, s.ID))
+			return false
+		}
+	}
+	return true
+}
+
+// siteRiskMatrix is the risk matrix of the request's site.
+func siteRiskMatrix(ctx context.Context) (*services.RiskMatrix, error) {
+	if s := siteFrom(ctx); s.RiskMatrix != nil {
+		return s.RiskMatrix, nil
+	}
+	return services.LoadRiskMatrix(settings().Data.RiskMatrixFile)
+}
+
+// roleAt places the model's role at the site's organization in prompts.
+func roleAt(s *sites.Site, role string) string {
+	if s.Organization == "" {
+		return role
+	}
+	return role + " at " + s.Organization
+}
+
+// standardsClause names the site's standards after "industry best
+// practices" in prompts.
+func standardsClause(s *sites.Site) string {
+	if len(s.Standards) == 0 {
+		return ""
+	}
+	return " and " + strings.Join(s.Standards, ", ")
+}
+
+// HandleListSitesV1 lists the sites the caller may use.
+func HandleListSitesV1(c *gin.Context) {
+	p := principal(c)
+	out := []models.Site{}
+	for _, s := range siteStore().List() {
+		if siteAllowed(p, s.ID) {
+			out = append(out, s.Site)
+		}
+	}
+	respond(c, http.StatusOK, out)
+}
+
+// checkSites reports the entries of ids that name no configured site.
+// AllSites is accepted as a grant of every site.
+func checkSites(ids []string) []models.FieldError {
+	var errs []models.FieldError
+	for i, id := range ids {
+		if _, ok := siteStore().Get(id); !ok && id != models.AllSites {
+			errs = append(errs, models.FieldError{Field: fmt.Sprintf("sites[%d]", i), Rule: "oneof", Message: fmt.Sprintf("unknown site %q", id)})
+		}
+	}
+	return errs
+}
@@ -2,9 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"pcst-ai/backend/catalog"
+	"pcst-ai/backend/errorcodes"
+	"pcst-ai/backend/ild"
 	"pcst-ai/backend/models"
 	"pcst-ai/backend/services"
 
@@ -12,40 +17,64 @@ import (
 )
 
 func HandleSearch(c *gin.Context) {
-	var req models.SearchRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var body models.LegacySearchRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Equipment and problem description are required"})
 		return
 	}
+	req := body.Request()
 
-	ctx := context.Background()
-	gemini, err := services.NewGeminiService(ctx)
+	details, err := recordAnalysis(c, models.AnalysisTroubleshooting, req, func(ctx context.Context) (models.TroubleshootingDetails, error) {
+		return analyzeTroubleshooting(ctx, req)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondLegacyErr(c, err)
 		return
 	}
-	defer gemini.Close()
 
-	errorCodeText := "None provided"
-	if req.ErrorCode != "" {
-		errorCodeText = req.ErrorCode
+	c.JSON(http.StatusOK, models.SearchResponse{
+		Success:   true,
+		Equipment: details.Equipment,
+		Response: models.ResponseSections{
+			Analysis:       details.Analysis,
+			Causes:         details.Causes,
+			Steps:          details.Steps,
+			SafetyWarnings: details.SafetyWarnings,
+			EquipmentNotes: details.EquipmentNotes,
+		},
+	})
+}
+
+func HandleTroubleshootingV1(c *gin.Context) {
+	var req models.SearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalid(c, "Equipment and problem description are required", err)
+		return
+	}
+
+	details, err := recordAnalysis(c, models.AnalysisTroubleshooting, req, func(ctx context.Context) (models.TroubleshootingDetails, error) {
+		return analyzeTroubleshooting(ctx, req)
+	})
+	if err != nil {
+		respondErr(c, err)
+		return
 	}
 
-	prompt := fmt.Sprintf(`You are an expert Process Control System Technician at Aramco.
+	respond(c, http.StatusOK, details)
+}
+
+// troubleshootingPrompt takes the role, the equipment and its category, the
+// problem, the error code, the reference sections and the safety guidelines.
+const troubleshootingPrompt = `You are an expert %s.
 
 Analyze the following troubleshooting request and provide a clear, structured response:
 
-EQUIPMENT: %s
+EQUIPMENT: %s (%s)
 PROBLEM: %s
 ERROR CODE: %s
-
+%s
 IMPORTANT SAFETY GUIDELINES:
-- Always prioritize safety over production
-- Follow Aramco safety protocols
-- Verify equipment isolation before maintenance
-- Use proper PPE and safety equipment
-- Never bypass safety systems
-- Follow lockout/tagout procedures
+- %s
 
 Please provide your response in this exact format:
 
@@ -71,57 +100,158 @@ SAFETY WARNINGS:
 EQUIPMENT NOTES:
 [Specific considerations for this equipment type]
 
-Provide your response in a clear, structured format that a technician can follow safely.`, req.Equipment, req.Problem, errorCodeText)
+Provide your response in a clear, structured format that a technician can follow safely.`
 
-	responseText, err := gemini.Generate(prompt)
+// troubleshootingGuidelines apply at every site; a site's own safety rules
+// follow the first.
+var troubleshootingGuidelines = []string{
+	"Always prioritize safety over production",
+	"Verify equipment isolation before maintenance",
+	"Use proper PPE and safety equipment",
+	"Never bypass safety systems",
+	"Follow lockout/tagout procedures",
+}
+
+func analyzeTroubleshooting(ctx context.Context, req models.SearchRequest) (models.TroubleshootingDetails, error) {
+	equipment, found, err := catalog.Resolve(siteCatalog(ctx), req.Equipment)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return models.TroubleshootingDetails{}, newAPIError(http.StatusInternalServerError, models.ErrCodeInternal, err)
+	}
+	if !found {
+		message := fmt.Sprintf("Unknown equipment %q", req.Equipment)
+		return models.TroubleshootingDetails{}, &apiError{
+			status:  http.StatusBadRequest,
+			code:    models.ErrCodeInvalidRequest,
+			message: message,
+			details: []models.FieldError{{Field: "equipment", Rule: "catalog", Message: message + "; see /api/v1/equipment for supported equipment"}},
+		}
 	}
 
-	analysis, causes, steps, safetyWarnings, equipmentNotes := services.ParseTroubleshootingResponse(responseText)
+	var loop *models.Loop
+	loopText := ""
+	if req.LoopTag != "" {
+		found, err := siteLoops(ctx).Get(req.LoopTag)
+		if errors.Is(err, ild.ErrNotFound) {
+			message := fmt.Sprintf("Unknown loop tag %q", req.LoopTag)
+			return models.TroubleshootingDetails{}, &apiError{
+				status:  http.StatusBadRequest,
+				code:    models.ErrCodeInvalidRequest,
+				message: message,
+				details: []models.FieldError{{Field: "loopTag", Rule: "ild", Message: message + "; see /api/v1/loops for imported loop diagrams"}},
+			}
+		}
+		if err != nil {
+			return models.TroubleshootingDetails{}, newAPIError(http.StatusInternalServerError, models.ErrCodeInternal, err)
+		}
+		loop = &found
+		loopText = "\n[L1] LOOP DIAGRAM (verified wiring in order from the field; reference these tags, junction boxes, terminals and I/O in the steps):\n" + ild.PromptContext(found) + "\n"
 
-	c.JSON(http.StatusOK, models.SearchResponse{
-		Success:   true,
-		Equipment: req.Equipment,
-		Response: models.ResponseSections{
-			Analysis:       analysis,
-			Causes:         causes,
-			Steps:          steps,
-			SafetyWarnings: safetyWarnings,
-			EquipmentNotes: equipmentNotes,
-		},
-	})
-}
+		// The loop's device identifies the manufacturer for error code
+		// lookup when the technician did not.
+		if device, ok := ild.Device(found); ok && req.Manufacturer == "" {
+			req.Manufacturer = device.Manufacturer
+			req.Model = device.Model
+		}
+	}
+
+	knowledge := catalog.PromptContext(equipment)
+	knowledgeText := ""
+	if knowledge != "" {
+		knowledgeText = "\n[K1] EQUIPMENT KNOWLEDGE (verified reference for this equipment type; prefer it over general knowledge):\n" + knowledge + "\n"
+	}
+
+	knownCodes, err := lookupErrorCodes(req, equipment)
+	if err != nil {
+		return models.TroubleshootingDetails{}, newAPIError(http.StatusInternalServerError, models.ErrCodeInternal, err)
+	}
+
+	errorCodeText := "None provided"
+	if req.ErrorCode != "" {
+		errorCodeText = req.ErrorCode
+		if device := strings.TrimSpace(req.Manufacturer + " " + req.Model); device != "" {
+			errorCodeText += " (device: " + device + ")"
+		}
+		if len(knownCodes) > 0 {
+			errorCodeText += "\nKNOWN ERROR CODE (verified vendor documentation; use this meaning and do not reinterpret it):\n" + errorcodes.PromptContext(knownCodes)
+		} else {
+			errorCodeText += "\nThis code is not in the vendor code database; say so if you are unsure of its meaning."
+		}
+	}
+
+	query := strings.Join([]string{equipment.Name, req.Problem, req.ErrorCode, req.Manufacturer, req.Model}, " ")
+	renderCtx, render := tracer.Start(ctx, "render prompt")
+	referenceText, documents := retrieve(renderCtx, query)
+	citations := sourceCitations(equipment, knowledge, knownCodes, loop)
+	citations = append(citations, documents...)
 
-func HandleGetEquipment(c *gin.Context) {
-	equipmentList := []string{
-		"Control Valve",
-		"Pressure Gauge",
-		"Displacement Level Transmitter",
-		"Flow Transmitter",
-		"Pressure Transmitter",
-		"Temperature Transmitter",
-		"Level Transmitter",
-		"Resistance Temperature Detector (RTD)",
-		"Thermocouple",
-		"Proximity Transducer",
-		"Control Panel",
-		"SCADA System",
-		"PLC",
-		"DCS",
-		"Safety Instrumented System (SIS)",
-		"Emergency Shutdown System (ESD)",
-		"Fire and Gas Detection System",
-		"Compressor",
-		"Pump",
-		"Turbine",
-		"Heat Exchanger",
-		"Separator",
-		"Storage Tank",
-		"Pipeline",
-		"Valve Actuator",
+	site := siteFrom(ctx)
+	guidelines := append([]string{troubleshootingGuidelines[0]}, site.SafetyRules...)
+	guidelines = append(guidelines, troubleshootingGuidelines[1:]...)
+
+	prompt := fmt.Sprintf(troubleshootingPrompt, roleAt(site, "Process Control System Technician"), equipment.Name, strings.ReplaceAll(equipment.Category, "-", " "), req.Problem, errorCodeText, knowledgeText+loopText+referenceText+citationGuide(citations), strings.Join(guidelines, "\n- "))
+
+	render.End()
+	responseText, err := generate(ctx, prompt)
+	if err != nil {
+		return models.TroubleshootingDetails{}, err
 	}
 
-	c.JSON(http.StatusOK, gin.H{"equipment": equipmentList})
+	parseCtx, parse := tracer.Start(ctx, "parse answer")
+	defer parse.End()
+	analysis, causes, steps, safetyWarnings, equipmentNotes, warnings := services.ParseTroubleshootingResponse(responseText)
+	noteParseWarnings(parseCtx, models.AnalysisTroubleshooting, warnings)
+
+	causes, causeSources := attribute("causes", causes, citations)
+	steps, stepSources := attribute("steps", steps, citations)
+	safetyWarnings, warningSources := attribute("safetyWarnings", safetyWarnings, citations)
+
+	return models.TroubleshootingDetails{
+		EquipmentID:      equipment.ID,
+		KnowledgeApplied: knowledge != "",
+		Equipment:        equipment.Name,
+		Analysis:         services.StripCitations(analysis),
+		Causes:           causes,
+		Steps:            steps,
+		SafetyWarnings:   safetyWarnings,
+		EquipmentNotes:   services.StripCitations(equipmentNotes),
+		KnownCodes:       nonNilCodes(knownCodes),
+		Loop:             loop,
+		Citations:        citations,
+		Attributions:     append(append(causeSources, stepSources...), warningSources...),
+	}, nil
+}
+
+// sourceCitations describes the catalog, error code and loop sources given
+// to the model, using the labels they carry in the prompt.
+func sourceCitations(equipment models.Equipment, knowledge string, codes []models.ErrorCode, loop *models.Loop) []models.Citation {
+	citations := []models.Citation{}
+	if knowledge != "" {
+		citations = append(citations, models.Citation{
+			Ref:      "K1",
+			Type:     models.CitationTypeCatalog,
+			SourceID: equipment.ID,
+			Title:    equipment.Name + " knowledge pack",
+			Excerpt:  truncate(knowledge, maxExcerptSize),
+		})
+	}
+	for i, code := range codes {
+		citations = append(citations, models.Citation{
+			Ref:      fmt.Sprintf("E%d", i+1),
+			Type:     models.CitationTypeErrorCode,
+			SourceID: code.ID,
+			Title:    errorcodes.Device(code) + " code " + code.Code,
+			Location: code.Source,
+			Excerpt:  truncate(code.Meaning, maxExcerptSize),
+		})
+	}
+	if loop != nil {
+		citations = append(citations, models.Citation{
+			Ref:      "L1",
+			Type:     models.CitationTypeLoop,
+			SourceID: loop.Tag,
+			Title:    "Loop diagram " + loop.Tag,
+			Excerpt:  truncate(loop.Description, maxExcerptSize),
+		})
+	}
+	return citations
 }
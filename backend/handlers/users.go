@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/auth"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/storage"
+)
+
+func HandleListUsersV1(c *gin.Context) {
+	users, err := analysisStore().ListUsers(c.Request.Context())
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, users)
+}
+
+func HandleCreateUserV1(c *gin.Context) {
+	var in models.UserInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondInvalid(c, "Invalid user", err)
+		return
+	}
+	fieldErrs := checkSites(in.Sites)
+	if in.Password == "" {
+		fieldErrs = append(fieldErrs, models.FieldError{Field: "password", Rule: "required", Message: "password is required"})
+	}
+	if len(fieldErrs) > 0 {
+		respondInvalidFields(c, "Invalid user", fieldErrs)
+		return
+	}
+
+	hash, err := auth.HashPassword(in.Password)
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	now := time.Now().UTC()
+	u := models.User{
+		ID:        newRecordID(),
+		Username:  strings.TrimSpace(in.Username),
+		Name:      in.Name,
+		Role:      in.Role,
+		Sites:     nonNilStrings(in.Sites),
+		Disabled:  in.Disabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := analysisStore().CreateUser(c.Request.Context(), u, hash); err != nil {
+		respondUserErr(c, err)
+		return
+	}
+	respond(c, http.StatusCreated, u)
+}
+
+// HandleUpdateUserV1 replaces an account. An empty password keeps the
+// current one.
+func HandleUpdateUserV1(c *gin.Context) {
+	var in models.UserInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondInvalid(c, "Invalid user", err)
+		return
+	}
+	if fieldErrs := checkSites(in.Sites); len(fieldErrs) > 0 {
+		respondInvalidFields(c, "Invalid user", fieldErrs)
+		return
+	}
+
+	store := analysisStore()
+	u, err := store.GetUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondUserErr(c, err)
+		return
+	}
+	if u.ID == principal(c).Subject && (in.Role != models.RoleAdmin || in.Disabled) {
+		respondError(c, http.StatusConflict, models.ErrCodeConflict, "you cannot remove your own admin access")
+		return
+	}
+
+	hash := ""
+	if in.Password != "" {
+		if hash, err = auth.HashPassword(in.Password); err != nil {
+			respondErr(c, err)
+			return
+		}
+	}
+	u.Username = strings.TrimSpace(in.Username)
+	u.Name = in.Name
+	u.Role = in.Role
+	u.Sites = nonNilStrings(in.Sites)
+	u.Disabled = in.Disabled
+	u.UpdatedAt = time.Now().UTC()
+	if err := store.UpdateUser(c.Request.Context(), u, hash); err != nil {
+		respondUserErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, u)
+}
+
+func HandleDeleteUserV1(c *gin.Context) {
+	store := analysisStore()
+	u, err := store.GetUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondUserErr(c, err)
+		return
+	}
+	if u.ID == principal(c).Subject {
+		respondError(c, http.StatusConflict, models.ErrCodeConflict, "you cannot delete your own account")
+		return
+	}
+	if err := store.DeleteUser(c.Request.Context(), u.ID); err != nil {
+		respondUserErr(c, err)
+		return
+	}
+	respond(c, http.StatusOK, u)
+}
+
+func respondUserErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		respondError(c, http.StatusNotFound, models.ErrCodeNotFound, err.Error())
+	case errors.Is(err, storage.ErrUserConflict):
+		respondError(c, http.StatusConflict, models.ErrCodeConflict, err.Error())
+	default:
+		respondErr(c, err)
+	}
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"pcst-ai/backend/middleware"
+	"pcst-ai/backend/models"
+)
+
+// slugPattern is what the slug rule accepts: catalog IDs.
+var slugPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+func init() {
+	// Report fields by their JSON name so error details match the request.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+		v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+			return slugPattern.MatchString(fl.Field().String())
+		})
+	}
+}
+
+// respondInvalid rejects a request that failed to bind, listing every
+// offending field.
+func respondInvalid(c *gin.Context, message string, err error) {
+	respondInvalidFields(c, message, bindingDetails(err))
+}
+
+func respondInvalidFields(c *gin.Context, message string, details []models.FieldError) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, models.Envelope{
+		Success: false,
+		Error: &models.APIError{
+			Code:    models.ErrCodeInvalidRequest,
+			Message: message,
+			Details: details,
+		},
+		RequestID: middleware.GetRequestID(c),
+		Metadata:  newMetadata(),
+	})
+}
+
+// bindingDetails explains a ShouldBindJSON failure field by field.
+func bindingDetails(err error) []models.FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]models.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			details = append(details, models.FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Message: fieldMessage(fe),
+			})
+		}
+		return details
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []models.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be %s", typeErr.Field, jsonTypeName(typeErr.Type)),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []models.FieldError{{Rule: "syntax", Message: "request body is not valid JSON"}}
+	}
+
+	if errors.Is(err, io.EOF) {
+		return []models.FieldError{{Rule: "required", Message: "request body is empty"}}
+	}
+
+	return []models.FieldError{{Message: err.Error()}}
+}
+
+// fieldPath is the field's JSON path below the request struct, such as
+// "elements[2].kind" for a nested field.
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	kind := fe.Kind()
+	if kind == reflect.Ptr {
+		kind = fe.Type().Elem().Kind()
+	}
+	isString := kind == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "gte", "min":
+		if isString {
+			return fmt.Sprintf("%s must be at least %s characters", fe.Field(), fe.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", fe.Field(), fe.Param())
+	case "lte", "max":
+		if isString {
+			return fmt.Sprintf("%s must be at most %s characters", fe.Field(), fe.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", fe.Field(), fe.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", fe.Field(), fe.Param())
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fe.Field(), fe.Param())
+	case "slug":
+		return fe.Field() + " may only contain lowercase letters, digits and hyphens"
+	}
+	return fmt.Sprintf("%s failed the %s rule", fe.Field(), fe.Tag())
+}
+
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a number"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
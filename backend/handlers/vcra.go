@@ -17,19 +17,44 @@ func HandleVCRA(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	gemini, err := services.NewGeminiService(ctx)
+	details, err := recordAnalysis(c, models.AnalysisVCRA, req, func(ctx context.Context) (models.VCRADetails, error) {
+		return analyzeVCRA(ctx, req)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondLegacyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.VCRAResponse{
+		Success:  true,
+		Response: details,
+	})
+}
+
+func HandleVCRAV1(c *gin.Context) {
+	var req models.VCRARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalid(c, "Control room logs are required", err)
 		return
 	}
-	defer gemini.Close()
 
-	prompt := fmt.Sprintf(`You are a Virtual Control Room Advisor for an oil and gas facility. Analyze the following control room logs and provide a detailed incident analysis.
+	details, err := recordAnalysis(c, models.AnalysisVCRA, req, func(ctx context.Context) (models.VCRADetails, error) {
+		return analyzeVCRA(ctx, req)
+	})
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, details)
+}
+
+// vcraPrompt takes the logs and the reference sections.
+const vcraPrompt = `You are a Virtual Control Room Advisor for an oil and gas facility. Analyze the following control room logs and provide a detailed incident analysis.
 
 CONTROL ROOM LOGS:
 %s
-
+%s
 Provide your analysis in this exact format:
 
 ROOT CAUSE:
@@ -47,28 +72,50 @@ IMMEDIATE ACTIONS:
 - [Action 3]
 
 RECOVERY TIMELINE:
-- [Timeline step 1]
-- [Timeline step 2]
-- [Timeline step 3]
+- [Phase] | [Time window from incident start, e.g. 0-15 min, within 2 hours, after 4 hours] | [Responsible role] | [Action]
+- [Phase] | [Time window] | [Responsible role] | [Action]
+- [Phase] | [Time window] | [Responsible role] | [Action]
+
+Use phases such as Containment, Stabilization, Investigation and Restoration. Give every time window in minutes, hours or days, and name one responsible role per step (e.g. Console Operator, Shift Supervisor, Field Operator, Instrument Technician).
+
+Be specific and actionable. Focus on immediate response and safety.`
 
-Be specific and actionable. Focus on immediate response and safety.`, req.Logs)
+func analyzeVCRA(ctx context.Context, req models.VCRARequest) (models.VCRADetails, error) {
+	renderCtx, render := tracer.Start(ctx, "render prompt")
+	referenceText, citations := retrieve(renderCtx, req.Logs)
 
-	responseText, err := gemini.Generate(prompt)
+	prompt := fmt.Sprintf(vcraPrompt, req.Logs, referenceText+citationGuide(citations))
+
+	render.End()
+	responseText, err := generate(ctx, prompt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return models.VCRADetails{}, err
 	}
 
-	rootCause, riskLevel, confidence, actions, timeline := services.ParseVCRAResponse(responseText)
+	parseCtx, parse := tracer.Start(ctx, "parse answer")
+	defer parse.End()
+	rootCause, riskLevel, confidence, actions, timeline, warnings := services.ParseVCRAResponse(responseText)
+	noteParseWarnings(parseCtx, models.AnalysisVCRA, warnings)
 
-	c.JSON(http.StatusOK, models.VCRAResponse{
-		Success: true,
-		Response: models.VCRADetails{
-			RootCause:  rootCause,
-			RiskLevel:  riskLevel,
-			Confidence: confidence,
-			Actions:    actions,
-			Timeline:   timeline,
-		},
-	})
+	actions, attributions := attribute("actions", actions, citations)
+	steps := make([]string, len(timeline))
+	for i, step := range timeline {
+		steps[i] = step.Action
+	}
+	steps, stepSources := attribute("timeline", steps, citations)
+	for i := range timeline {
+		timeline[i].Action = steps[i]
+		timeline[i].Text = services.StripCitations(timeline[i].Text)
+	}
+	attributions = append(attributions, stepSources...)
+
+	return models.VCRADetails{
+		RootCause:    services.StripCitations(rootCause),
+		RiskLevel:    riskLevel,
+		Confidence:   confidence,
+		Actions:      actions,
+		Timeline:     timeline,
+		Citations:    citations,
+		Attributions: attributions,
+	}, nil
 }
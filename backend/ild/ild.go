@@ -0,0 +1,340 @@
+// Package ild stores instrument loop diagrams so troubleshooting can follow
+// a loop's actual wiring path.
+package ild
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+var ErrNotFound = errors.New("loop not found")
+
+// Store is the loop repository's persistence boundary. Tags are matched
+// case-insensitively.
+type Store interface {
+	List() ([]models.Loop, error)
+	Get(tag string) (models.Loop, error)
+	Import(loops []models.LoopInput) (models.ImportResult, error)
+	Delete(tag string) (models.Loop, error)
+}
+
+// FileStore keeps the repository in a JSON file, rewriting it on every
+// change. A missing file starts an empty repository.
+type FileStore struct {
+	path  string
+	mu    sync.RWMutex
+	loops []models.Loop
+}
+
+// OpenFile loads the repository at path. An empty path keeps it in memory
+// only.
+func OpenFile(path string) (*FileStore, error) {
+	s := &FileStore{path: path, loops: []models.Loop{}}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("reading loop diagrams: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.loops); err != nil {
+		return nil, fmt.Errorf("parsing loop diagrams: %w", err)
+	}
+	return s, nil
+}
+
+func (s *FileStore) List() ([]models.Loop, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	loops := make([]models.Loop, len(s.loops))
+	copy(loops, s.loops)
+	return loops, nil
+}
+
+func (s *FileStore) Get(tag string) (models.Loop, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i := s.index(tag); i >= 0 {
+		return s.loops[i], nil
+	}
+	return models.Loop{}, ErrNotFound
+}
+
+// Import adds loops, replacing the whole diagram of any loop already
+// present with the same tag.
+func (s *FileStore) Import(loops []models.LoopInput) (models.ImportResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := append([]models.Loop{}, s.loops...)
+	var result models.ImportResult
+	now := time.Now().UTC()
+	for _, in := range loops {
+		loop := models.Loop{
+			Tag:         NormalizeTag(in.Tag),
+			Description: strings.TrimSpace(in.Description),
+			Elements:    in.Elements,
+			UpdatedAt:   now,
+		}
+		if i := s.index(loop.Tag); i >= 0 {
+			s.loops[i] = loop
+			result.Updated++
+			continue
+		}
+		s.loops = append(s.loops, loop)
+		result.Added++
+	}
+	sort.SliceStable(s.loops, func(i, j int) bool { return s.loops[i].Tag < s.loops[j].Tag })
+
+	if err := s.save(); err != nil {
+		s.loops = previous
+		return models.ImportResult{}, err
+	}
+	return result, nil
+}
+
+func (s *FileStore) Delete(tag string) (models.Loop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(tag)
+	if i < 0 {
+		return models.Loop{}, ErrNotFound
+	}
+
+	previous := s.loops
+	loop := s.loops[i]
+	s.loops = append(append([]models.Loop{}, s.loops[:i]...), s.loops[i+1:]...)
+	if err := s.save(); err != nil {
+		s.loops = previous
+		return models.Loop{}, err
+	}
+	return loop, nil
+}
+
+func (s *FileStore) index(tag string) int {
+	tag = NormalizeTag(tag)
+	for i, loop := range s.loops {
+		if loop.Tag == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *FileStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.loops, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("writing loop diagrams: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing loop diagrams: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing loop diagrams: %w", err)
+	}
+	return nil
+}
+
+// NormalizeTag is the form tags are stored and compared in.
+func NormalizeTag(tag string) string {
+	return strings.ToUpper(strings.TrimSpace(tag))
+}
+
+// Filter returns the loops whose tag, description or element names contain
+// query, sorted by tag.
+func Filter(loops []models.Loop, query string) []models.Loop {
+	query = strings.ToLower(strings.TrimSpace(query))
+	out := make([]models.Loop, 0, len(loops))
+	for _, loop := range loops {
+		if query == "" || contains(loop, query) {
+			out = append(out, loop)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Tag < out[j].Tag })
+	return out
+}
+
+func contains(loop models.Loop, query string) bool {
+	if strings.Contains(strings.ToLower(loop.Tag), query) || strings.Contains(strings.ToLower(loop.Description), query) {
+		return true
+	}
+	for _, e := range loop.Elements {
+		if strings.Contains(strings.ToLower(e.Name), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Device returns the loop's first field device, if any.
+func Device(loop models.Loop) (models.LoopElement, bool) {
+	for _, e := range loop.Elements {
+		if e.Kind == models.LoopElementDevice {
+			return e, true
+		}
+	}
+	return models.LoopElement{}, false
+}
+
+var kindLabels = map[string]string{
+	models.LoopElementDevice:      "Field device",
+	models.LoopElementJunctionBox: "Junction box",
+	models.LoopElementMarshalling: "Marshalling",
+	models.LoopElementIO:          "I/O",
+	models.LoopElementPower:       "Power",
+}
+
+// PromptContext renders a loop's wiring path as numbered prompt lines.
+func PromptContext(loop models.Loop) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Loop %s", loop.Tag)
+	if loop.Description != "" {
+		fmt.Fprintf(&b, ": %s", loop.Description)
+	}
+	b.WriteString("\n")
+
+	for i, e := range loop.Elements {
+		fmt.Fprintf(&b, "%d. %s %s", i+1, kindLabels[e.Kind], e.Name)
+		var details []string
+		if e.Description != "" {
+			details = append(details, e.Description)
+		}
+		if device := strings.TrimSpace(e.Manufacturer + " " + e.Model); device != "" {
+			details = append(details, device)
+		}
+		if e.Card != "" {
+			details = append(details, "card "+e.Card)
+		}
+		if e.Channel != "" {
+			details = append(details, "channel "+e.Channel)
+		}
+		if e.Terminals != "" {
+			details = append(details, "terminals "+e.Terminals)
+		}
+		if e.Cable != "" {
+			details = append(details, "cable "+e.Cable)
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(details, "; "))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+var csvColumns = map[string]string{
+	"looptag":         "loopTag",
+	"loopdescription": "loopDescription",
+	"kind":            "kind",
+	"name":            "name",
+	"description":     "description",
+	"terminals":       "terminals",
+	"cable":           "cable",
+	"manufacturer":    "manufacturer",
+	"model":           "model",
+	"card":            "card",
+	"channel":         "channel",
+}
+
+// ParseCSV reads one row per loop element, with a header line naming the
+// columns: loop_tag, loop_description, kind, name, description, terminals,
+// cable, manufacturer, model, card, channel. Rows of a loop are kept in file
+// order, which is taken as the wiring order.
+func ParseCSV(r io.Reader) ([]models.LoopInput, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		key := strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		column, ok := csvColumns[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[i] = column
+	}
+
+	var loops []models.LoopInput
+	index := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return loops, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var tag, description string
+		var e models.LoopElement
+		for i, value := range record {
+			switch columns[i] {
+			case "loopTag":
+				tag = value
+			case "loopDescription":
+				description = value
+			case "kind":
+				e.Kind = strings.ToLower(value)
+			case "name":
+				e.Name = value
+			case "description":
+				e.Description = value
+			case "terminals":
+				e.Terminals = value
+			case "cable":
+				e.Cable = value
+			case "manufacturer":
+				e.Manufacturer = value
+			case "model":
+				e.Model = value
+			case "card":
+				e.Card = value
+			case "channel":
+				e.Channel = value
+			}
+		}
+
+		key := NormalizeTag(tag)
+		i, ok := index[key]
+		if !ok {
+			i = len(loops)
+			index[key] = i
+			loops = append(loops, models.LoopInput{Tag: tag})
+		}
+		if loops[i].Description == "" {
+			loops[i].Description = description
+		}
+		loops[i].Elements = append(loops[i].Elements, e)
+	}
+}
@@ -0,0 +1,178 @@
+// Package logging sets up the server's structured log. Records written with
+// a request's context carry its request ID, so a provider call or a storage
+// failure can be traced back to the request that caused it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"pcst-ai/backend/config"
+)
+
+// New returns a logger writing to w in the configured format and level.
+// Text given to UserText is redacted unless the configuration says not to.
+func New(w io.Writer, cfg config.Logging) *slog.Logger {
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Level))
+
+	opts := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if text, ok := a.Value.Any().(userText); ok {
+				if cfg.RedactUserText {
+					a.Value = slog.StringValue(fmt.Sprintf("[redacted: %d chars]", len(text)))
+				} else {
+					a.Value = slog.StringValue(string(text))
+				}
+			}
+			return a
+		},
+	}
+
+	var h slog.Handler
+	if cfg.Format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// userText is text typed by a user, or derived from it such as a prompt.
+type userText string
+
+// UserText is an attribute holding text typed by a user, or derived from it
+// such as a prompt or the model's answer to one.
+func UserText(key, text string) slog.Attr {
+	return slog.Any(key, userText(text))
+}
+
+// contextHandler adds the request ID from the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("requestId", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type requestIDKey struct{}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Fields collects what happened while handling a request, to be logged in
+// one record when it finishes. A nil *Fields ignores everything, so code
+// running outside a request need not check.
+type Fields struct {
+	mu            sync.Mutex
+	user          string
+	site          string
+	model         string
+	promptTokens  int
+	outputTokens  int
+	parseWarnings []string
+}
+
+type fieldsKey struct{}
+
+func WithFields(ctx context.Context) (context.Context, *Fields) {
+	f := &Fields{}
+	return context.WithValue(ctx, fieldsKey{}, f), f
+}
+
+// FieldsFrom returns the request's fields, or nil outside a request.
+func FieldsFrom(ctx context.Context) *Fields {
+	f, _ := ctx.Value(fieldsKey{}).(*Fields)
+	return f
+}
+
+func (f *Fields) SetUser(user string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.user = user
+}
+
+func (f *Fields) SetSite(site string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.site = site
+}
+
+// AddGeneration counts one call to the model.
+func (f *Fields) AddGeneration(model string, promptTokens, outputTokens int) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.model = model
+	f.promptTokens += promptTokens
+	f.outputTokens += outputTokens
+}
+
+// AddParseWarnings records what the parser could not find in the model's
+// answer.
+func (f *Fields) AddParseWarnings(warnings ...string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.parseWarnings = append(f.parseWarnings, warnings...)
+}
+
+// Attrs returns the fields that were set.
+func (f *Fields) Attrs() []slog.Attr {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var attrs []slog.Attr
+	if f.user != "" {
+		attrs = append(attrs, slog.String("user", f.user))
+	}
+	if f.site != "" {
+		attrs = append(attrs, slog.String("site", f.site))
+	}
+	if f.model != "" {
+		attrs = append(attrs,
+			slog.String("model", f.model),
+			slog.Int("promptTokens", f.promptTokens),
+			slog.Int("outputTokens", f.outputTokens),
+		)
+	}
+	if len(f.parseWarnings) > 0 {
+		attrs = append(attrs, slog.Any("parseWarnings", f.parseWarnings))
+	}
+	return attrs
+}
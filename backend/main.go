@@ -1,50 +1,163 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"pcst-ai/backend/config"
 	"pcst-ai/backend/handlers"
+	"pcst-ai/backend/logging"
+	"pcst-ai/backend/router"
+	"pcst-ai/backend/tracing"
 )
 
 func main() {
-	if err := godotenv.Load("../.env"); err != nil {
-		log.Println("No .env file found, using system environment variables")
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
 	}
+	handlers.SetConfig(cfg)
 
-	r := gin.Default()
+	slog.SetDefault(logging.New(os.Stdout, cfg.Logging))
+	if cfg.Logging.Format == "json" && os.Getenv(gin.EnvGinMode) == "" {
+		// gin's debug output is not JSON.
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+
+	equipment, err := handlers.OpenEquipmentCatalog()
+	if err != nil {
+		fatal("failed to open equipment catalog", err)
+	}
+	handlers.SetEquipmentCatalog(equipment)
+
+	errorCodes, err := handlers.OpenErrorCodeStore()
+	if err != nil {
+		fatal("failed to open error code database", err)
+	}
+	handlers.SetErrorCodeStore(errorCodes)
 
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:5174", "http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
-		AllowCredentials: true,
-	}))
+	loops, err := handlers.OpenLoopStore()
+	if err != nil {
+		fatal("failed to open loop diagrams", err)
+	}
+	handlers.SetLoopStore(loops)
+
+	siteRegistry, err := handlers.OpenSites()
+	if err != nil {
+		fatal("failed to load sites", err)
+	}
+	handlers.SetSites(siteRegistry)
 
-	api := r.Group("/api")
-	{
-		api.POST("/search", handlers.HandleSearch)
-		api.GET("/equipment", handlers.HandleGetEquipment)
-		api.POST("/vcra/analyze", handlers.HandleVCRA)
-		api.POST("/safety/analyze", handlers.HandleSafety)
-		api.POST("/corrosion/analyze", handlers.HandleCorrosion)
+	documents, err := handlers.OpenDocumentIndex()
+	if err != nil {
+		fatal("failed to open document index", err)
 	}
+	handlers.SetDocumentIndex(documents)
 
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+	analyses, err := handlers.OpenAnalysisStore()
+	if err != nil {
+		fatal("failed to open analysis storage", err)
+	}
+	handlers.SetAnalysisStore(analyses)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	local, authenticators, err := handlers.OpenAuth(context.Background())
+	if err != nil {
+		fatal("failed to configure authentication", err)
 	}
+	handlers.SetAuth(local, authenticators)
 
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	// Requests run under work, so analyses still going when the grace
+	// period ends can be cancelled.
+	work, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+	srv := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      router.New(cfg),
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout),
+		BaseContext:  func(net.Listener) context.Context { return work },
 	}
+
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		tls := cfg.Server.TLSCertFile != ""
+		slog.Info("server starting", "addr", cfg.Server.Addr, "tls", tls)
+		if tls {
+			served <- srv.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			served <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-served:
+		fatal("failed to start server", err)
+	case <-signals.Done():
+	}
+	// A second signal stops the server at once.
+	stop()
+
+	grace := time.Duration(cfg.Server.ShutdownGracePeriod)
+	slog.Info("shutting down: no new requests accepted, waiting for running ones", "gracePeriod", grace.String())
+	drain(srv, grace, cancelWork)
+
+	// Analyses and their audit entries are written before their requests
+	// finish, so once the server has drained they are all stored.
+	ctx, cancel := context.WithTimeout(context.Background(), cancelledDrainTimeout)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("failed to flush traces", "error", err)
+	}
+	if err := analyses.Close(); err != nil {
+		slog.Warn("failed to close analysis storage", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// cancelledDrainTimeout is how long requests cancelled at the end of the
+// grace period get to record their failure, and how long flushing traces
+// may take.
+const cancelledDrainTimeout = 10 * time.Second
+
+// drain stops srv accepting connections and waits up to grace for running
+// requests, including batch locations still queued. Requests still
+// running then are cancelled through cancelWork and given a little longer
+// to record their failure before their connections are closed.
+func drain(srv *http.Server, grace time.Duration, cancelWork context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err == nil {
+		return
+	}
+
+	slog.Warn("grace period over, cancelling running requests")
+	cancelWork()
+	ctx, cancel = context.WithTimeout(context.Background(), cancelledDrainTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("closing connections of requests that did not stop", "error", err)
+		srv.Close()
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,109 @@
+// Package metrics holds the server's Prometheus metrics, served at /metrics.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric below plus the Go runtime and process ones.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_http_requests_total",
+		Help: "HTTP requests handled, by route pattern and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pcst_http_request_duration_seconds",
+		Help:    "Time to handle an HTTP request, by route pattern.",
+		Buckets: []float64{.005, .025, .1, .5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"method", "route"})
+
+	LLMRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_llm_requests_total",
+		Help: "Calls to the model provider, by model.",
+	}, []string{"model"})
+
+	LLMErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_llm_errors_total",
+		Help: "Calls to the model provider that failed, by model.",
+	}, []string{"model"})
+
+	LLMDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pcst_llm_request_duration_seconds",
+		Help:    "Time the model provider took to answer, by model.",
+		Buckets: []float64{.5, 1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"model"})
+
+	LLMTokens = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_llm_tokens_total",
+		Help: "Tokens consumed, by model and type (prompt or output).",
+	}, []string{"model", "type"})
+
+	ParseWarnings = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_parse_warnings_total",
+		Help: "Sections or values the parser could not find in the model's answer, by analyzer.",
+	}, []string{"analyzer"})
+
+	CacheHits = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_cache_hits_total",
+		Help: "Lookups answered from a cache, by cache.",
+	}, []string{"cache"})
+
+	CacheMisses = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_cache_misses_total",
+		Help: "Lookups a cache could not answer, by cache.",
+	}, []string{"cache"})
+
+	BatchQueueDepth = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "pcst_batch_queue_depth",
+		Help: "Batch locations waiting for a free batch slot.",
+	})
+
+	ProviderInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "pcst_provider_in_flight",
+		Help: "Calls to the model provider in progress.",
+	})
+
+	AuditFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_audit_append_failures_total",
+		Help: "Audit entries that could not be appended, by kind. Any increase means requests were refused.",
+	}, []string{"kind"})
+
+	RiskLevels = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "pcst_analysis_risk_levels_total",
+		Help: "Successful analyses by analyzer and the risk level of their result.",
+	}, []string{"analyzer", "level"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveLLM records one call to the model provider.
+func ObserveLLM(model string, elapsed time.Duration, promptTokens, outputTokens int, err error) {
+	LLMRequests.WithLabelValues(model).Inc()
+	LLMDuration.WithLabelValues(model).Observe(elapsed.Seconds())
+	LLMTokens.WithLabelValues(model, "prompt").Add(float64(promptTokens))
+	LLMTokens.WithLabelValues(model, "output").Add(float64(outputTokens))
+	if err != nil {
+		LLMErrors.WithLabelValues(model).Inc()
+	}
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
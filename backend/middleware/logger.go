@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/logging"
+)
+
+// Logger writes one record per request once it has been handled, with the
+// fields the handlers added along the way. It replaces gin's access log and
+// must run after RequestID.
+func Logger(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		ctx, fields := logging.WithFields(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("endpoint", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("latencyMs", time.Since(started).Milliseconds()),
+			slog.String("clientIp", c.ClientIP()),
+		}
+		// Search and filter values are typed by users.
+		if query := c.Request.URL.RawQuery; query != "" {
+			attrs = append(attrs, logging.UserText("query", query))
+		}
+		attrs = append(attrs, fields.Attrs()...)
+		log.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AnalysisTroubleshooting = "troubleshooting"
+	AnalysisVCRA            = "vcra"
+	AnalysisSafety          = "safety"
+	AnalysisCorrosion       = "corrosion"
+
+	AnalysisSucceeded = "succeeded"
+	AnalysisFailed    = "failed"
+)
+
+// AnalysisSummary is what history lists show for an analysis. Equipment
+// and RiskLevel are copied out of the result so history can be filtered on
+// them.
+type AnalysisSummary struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	RequestID     string    `json:"requestId"`
+	User          string    `json:"user"`
+	Site          string    `json:"site"`
+	Equipment     string    `json:"equipment"`
+	RiskLevel     string    `json:"riskLevel"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error"`
+	Model         string    `json:"model"`
+	PromptVersion string    `json:"promptVersion"`
+	StartedAt     time.Time `json:"startedAt"`
+	CompletedAt   time.Time `json:"completedAt"`
+	DurationMs    int64     `json:"durationMs"`
+}
+
+// AnalysisRecord is the stored trace of one analyzer run: what was asked,
+// the prompt actually sent, what the model returned and what was parsed
+// from it.
+type AnalysisRecord struct {
+	AnalysisSummary
+	Request   json.RawMessage `json:"request"`
+	Prompt    string          `json:"prompt"`
+	RawOutput string          `json:"rawOutput"`
+	Result    json.RawMessage `json:"result"`
+	// Approval is the engineer sign-off of a safety assessment, or null.
+	Approval *Approval `json:"approval"`
+}
+
+// HistoryPage is one page of analyses. NextCursor fetches the following
+// page and is empty on the last one.
+type HistoryPage struct {
+	Items      []AnalysisSummary `json:"items"`
+	NextCursor string            `json:"nextCursor"`
+}
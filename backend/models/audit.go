@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditAnalysis = "analysis"
+	AuditApproval = "approval"
+)
+
+// AuditEntry is one link of the audit chain. Hash covers PrevHash and every
+// other field, so changing, removing or reordering entries breaks the chain
+// from that point on. Response is the parsed result of an analysis or the
+// decision of an approval.
+type AuditEntry struct {
+	Seq           int64           `json:"seq"`
+	Kind          string          `json:"kind"`
+	AnalysisID    string          `json:"analysisId"`
+	Type          string          `json:"type"`
+	User          string          `json:"user"`
+	Site          string          `json:"site"`
+	Model         string          `json:"model"`
+	PromptVersion string          `json:"promptVersion"`
+	Request       json.RawMessage `json:"request"`
+	Response      json.RawMessage `json:"response"`
+	Error         string          `json:"error"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	PrevHash      string          `json:"prevHash"`
+	Hash          string          `json:"hash"`
+}
+
+// AuditVerification is the outcome of walking the chain. When Valid is
+// false, FailedSeq is the first entry that does not check out and Problem
+// says why. HeadHash is the hash of the last entry checked; recording it
+// elsewhere lets a later verification prove nothing before it was rewritten.
+type AuditVerification struct {
+	Valid     bool      `json:"valid"`
+	Entries   int64     `json:"entries"`
+	HeadHash  string    `json:"headHash"`
+	FailedSeq *int64    `json:"failedSeq"`
+	Problem   string    `json:"problem"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+const (
+	DocumentKindManual   = "manual"
+	DocumentKindSOP      = "sop"
+	DocumentKindBulletin = "bulletin"
+	DocumentKindOther    = "other"
+)
+
+// Document is one ingested manual, procedure or bulletin. Its text is held
+// in the retrieval index as passages.
+type Document struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	Filename    string    `json:"filename"`
+	Format      string    `json:"format"`
+	Pages       int       `json:"pages"`
+	Passages    int       `json:"passages"`
+	Embedded    bool      `json:"embedded"`
+	Warnings    []string  `json:"warnings"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// DocumentUpload describes the multipart form accepted when ingesting a
+// document.
+type DocumentUpload struct {
+	File        string `json:"file" binding:"required" format:"binary"`
+	Title       string `json:"title" binding:"max=200"`
+	Kind        string `json:"kind" binding:"omitempty,oneof=manual sop bulletin other"`
+	Description string `json:"description" binding:"max=2000"`
+}
+
+// Passage is a retrieved piece of a document. Page is 0 when the source has
+// no pages.
+type Passage struct {
+	DocumentID string  `json:"documentId"`
+	Title      string  `json:"title"`
+	Section    string  `json:"section"`
+	Page       int     `json:"page"`
+	Text       string  `json:"text"`
+	Score      float64 `json:"score"`
+}
+
+// Citation points a response at the source it drew on. Ref is the label
+// the model was given, such as "D1".
+type Citation struct {
+	Ref      string `json:"ref"`
+	Type     string `json:"type"`
+	SourceID string `json:"sourceId"`
+	Title    string `json:"title"`
+	Location string `json:"location"`
+	Excerpt  string `json:"excerpt"`
+}
+
+const (
+	CitationTypeDocument  = "document"
+	CitationTypeCatalog   = "catalog"
+	CitationTypeErrorCode = "error-code"
+	CitationTypeLoop      = "loop"
+)
+
+// Attribution ties one item of a response list, such as causes[2], to the
+// citations it was drawn from. Items with no valid citation are marked
+// ModelGenerated: they come from the model's general knowledge.
+type Attribution struct {
+	Section        string   `json:"section"`
+	Index          int      `json:"index"`
+	Refs           []string `json:"refs"`
+	ModelGenerated bool     `json:"modelGenerated"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	ErrCodeInvalidRequest      = "INVALID_REQUEST"
+	ErrCodeProviderUnavailable = "PROVIDER_UNAVAILABLE"
+	ErrCodeProviderError       = "PROVIDER_ERROR"
+	ErrCodeNotFound            = "NOT_FOUND"
+	ErrCodeConflict            = "CONFLICT"
+	ErrCodeUnauthorized        = "UNAUTHORIZED"
+	ErrCodeForbidden           = "FORBIDDEN"
+	ErrCodeInternal            = "INTERNAL_ERROR"
+)
+
+// Envelope is the response shape of every /api/v1 route. Data is set on
+// success and Error on failure.
+type Envelope struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *APIError   `json:"error,omitempty"`
+	RequestID string      `json:"requestId"`
+	Metadata  Metadata    `json:"metadata"`
+}
+
+type APIError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// FieldError explains why one request field was rejected. Field is the JSON
+// name of the field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Metadata struct {
+	APIVersion string    `json:"apiVersion"`
+	Timestamp  time.Time `json:"timestamp"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+const (
+	CategoryFieldInstruments  = "field-instruments"
+	CategoryControlSystems    = "control-systems"
+	CategoryRotatingEquipment = "rotating-equipment"
+	CategoryStaticEquipment   = "static-equipment"
+)
+
+// Equipment is one entry of the equipment catalog. ID is a stable slug that
+// clients may store; Name and Aliases are what technicians type.
+type Equipment struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	Category     string         `json:"category"`
+	Description  string         `json:"description"`
+	FailureModes []string       `json:"failureModes"`
+	Vendors      []Vendor       `json:"vendors"`
+	Aliases      []string       `json:"aliases"`
+	Knowledge    *KnowledgePack `json:"knowledge,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+// KnowledgePack is reference material for an equipment type that is given
+// to the model as verified context whenever that equipment is selected.
+type KnowledgePack struct {
+	MeasurementPrinciple string   `json:"measurementPrinciple" binding:"max=2000"`
+	Diagnostics          []string `json:"diagnostics"`
+	TypicalChecks        []string `json:"typicalChecks"`
+	Cautions             []string `json:"cautions"`
+}
+
+// Empty reports whether k holds nothing, as a deleted pack does.
+func (k *KnowledgePack) Empty() bool {
+	return k == nil || (k.MeasurementPrinciple == "" && len(k.Diagnostics) == 0 && len(k.TypicalChecks) == 0 && len(k.Cautions) == 0)
+}
+
+type Vendor struct {
+	Name   string   `json:"name" binding:"required"`
+	Models []string `json:"models"`
+}
+
+// EquipmentInput is the body of create and update requests. ID may be
+// omitted on create, in which case it is derived from Name.
+type EquipmentInput struct {
+	ID           string         `json:"id" binding:"omitempty,max=64,slug"`
+	Name         string         `json:"name" binding:"required,max=200"`
+	Category     string         `json:"category" binding:"required,oneof=field-instruments control-systems rotating-equipment static-equipment"`
+	Description  string         `json:"description" binding:"max=2000"`
+	FailureModes []string       `json:"failureModes"`
+	Vendors      []Vendor       `json:"vendors" binding:"dive"`
+	Aliases      []string       `json:"aliases"`
+	Knowledge    *KnowledgePack `json:"knowledge"`
+}
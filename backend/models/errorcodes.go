@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ErrorCode is a vendor's documented meaning of a device error, alarm or
+// diagnostic code. ModelFamily is a model prefix such as "EJA" and may be
+// empty when the code applies to every model of the manufacturer.
+type ErrorCode struct {
+	ID           string    `json:"id"`
+	Manufacturer string    `json:"manufacturer"`
+	ModelFamily  string    `json:"modelFamily"`
+	Code         string    `json:"code"`
+	Meaning      string    `json:"meaning"`
+	Action       string    `json:"action"`
+	Severity     string    `json:"severity"`
+	Source       string    `json:"source"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type ErrorCodeInput struct {
+	Manufacturer string `json:"manufacturer" binding:"required,max=100"`
+	ModelFamily  string `json:"modelFamily" binding:"max=100"`
+	Code         string `json:"code" binding:"required,max=50"`
+	Meaning      string `json:"meaning" binding:"required,max=2000"`
+	Action       string `json:"action" binding:"max=2000"`
+	Severity     string `json:"severity" binding:"max=50"`
+	Source       string `json:"source" binding:"max=200"`
+}
+
+type ImportResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// ItemRef points at one entry of a list in an analysis result, e.g. the
+// second cause (Section "causes", Index 1). Sections are the JSON names of
+// the result's lists.
+type ItemRef struct {
+	Section string `json:"section" binding:"required"`
+	Index   int    `json:"index" binding:"gte=0"`
+}
+
+// FeedbackInput is a technician's verdict on an analysis. Correct marks the
+// causes or actions that turned out to be right, Incorrect the ones that
+// were wrong. TimeToFixMinutes is how long the real fix took.
+type FeedbackInput struct {
+	Rating           int       `json:"rating" binding:"required,min=1,max=5"`
+	Correct          []ItemRef `json:"correct" binding:"omitempty,dive"`
+	Incorrect        []ItemRef `json:"incorrect" binding:"omitempty,dive"`
+	Resolution       string    `json:"resolution" binding:"max=4000"`
+	TimeToFixMinutes *int      `json:"timeToFixMinutes" binding:"omitempty,gte=0"`
+	Comment          string    `json:"comment" binding:"max=2000"`
+}
+
+type Feedback struct {
+	ID               string    `json:"id"`
+	AnalysisID       string    `json:"analysisId"`
+	User             string    `json:"user"`
+	Site             string    `json:"site"`
+	Rating           int       `json:"rating"`
+	Correct          []ItemRef `json:"correct"`
+	Incorrect        []ItemRef `json:"incorrect"`
+	Resolution       string    `json:"resolution"`
+	TimeToFixMinutes *int      `json:"timeToFixMinutes"`
+	Comment          string    `json:"comment"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// FeedbackOutcome is one piece of feedback joined with the analysis it
+// rates, as the accuracy report consumes it.
+type FeedbackOutcome struct {
+	Type             string
+	Equipment        string
+	Rating           int
+	Correct          int
+	Incorrect        int
+	TimeToFixMinutes *int
+	CreatedAt        time.Time
+}
+
+// AccuracyReport summarizes feedback per analyzer and equipment, bucketed
+// into periods of Interval (day, week or month) starting at Period.Start.
+type AccuracyReport struct {
+	Interval string          `json:"interval"`
+	Groups   []AccuracyGroup `json:"groups"`
+}
+
+type AccuracyGroup struct {
+	Type      string           `json:"type"`
+	Equipment string           `json:"equipment"`
+	Total     AccuracyStats    `json:"total"`
+	Periods   []AccuracyPeriod `json:"periods"`
+}
+
+type AccuracyPeriod struct {
+	Start time.Time `json:"start"`
+	AccuracyStats
+}
+
+// AccuracyStats aggregates feedback. AccuracyRate is the share of feedback
+// that marked at least one item correct and none wrong; HelpfulRate the
+// share rated 4 or 5. AverageTimeToFixMinutes is null when no feedback
+// recorded a time.
+type AccuracyStats struct {
+	Feedback                int      `json:"feedback"`
+	AverageRating           float64  `json:"averageRating"`
+	AccuracyRate            float64  `json:"accuracyRate"`
+	HelpfulRate             float64  `json:"helpfulRate"`
+	CorrectItems            int      `json:"correctItems"`
+	IncorrectItems          int      `json:"incorrectItems"`
+	AverageTimeToFixMinutes *float64 `json:"averageTimeToFixMinutes"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	Ready    = "ready"
+	NotReady = "not ready"
+
+	CheckOK      = "ok"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped"
+)
+
+// Readiness says whether the server can take analyses. Status is NotReady
+// when any check failed. Results are reused for a while, so CheckedAt may
+// be a little in the past.
+type Readiness struct {
+	Status    string        `json:"status"`
+	Checks    []HealthCheck `json:"checks"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+// HealthCheck is the outcome of checking one dependency. Detail explains a
+// failure or a skip.
+type HealthCheck struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Detail    string `json:"detail"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// BuildInfo identifies the running server. Commit and CommitTime are empty
+// when the binary was not built from a git checkout.
+type BuildInfo struct {
+	Version        string            `json:"version"`
+	Commit         string            `json:"commit"`
+	CommitTime     string            `json:"commitTime"`
+	Modified       bool              `json:"modified"`
+	GoVersion      string            `json:"goVersion"`
+	StartedAt      time.Time         `json:"startedAt"`
+	PromptVersions map[string]string `json:"promptVersions"`
+}
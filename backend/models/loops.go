@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+const (
+	LoopElementDevice      = "device"
+	LoopElementJunctionBox = "junction-box"
+	LoopElementMarshalling = "marshalling"
+	LoopElementIO          = "io"
+	LoopElementPower       = "power"
+)
+
+// Loop is one instrument loop diagram (ILD). Elements are in wiring order,
+// from the field device to the I/O channel and power source.
+type Loop struct {
+	Tag         string        `json:"tag"`
+	Description string        `json:"description"`
+	Elements    []LoopElement `json:"elements"`
+	UpdatedAt   time.Time     `json:"updatedAt"`
+}
+
+// LoopElement is one point on a loop's wiring path. Manufacturer and Model
+// apply to devices, Card and Channel to I/O.
+type LoopElement struct {
+	Kind         string `json:"kind" binding:"required,oneof=device junction-box marshalling io power"`
+	Name         string `json:"name" binding:"required,max=100"`
+	Description  string `json:"description" binding:"max=500"`
+	Terminals    string `json:"terminals" binding:"max=200"`
+	Cable        string `json:"cable" binding:"max=100"`
+	Manufacturer string `json:"manufacturer" binding:"max=100"`
+	Model        string `json:"model" binding:"max=100"`
+	Card         string `json:"card" binding:"max=100"`
+	Channel      string `json:"channel" binding:"max=100"`
+}
+
+type LoopInput struct {
+	Tag         string        `json:"tag" binding:"required,max=64"`
+	Description string        `json:"description" binding:"max=500"`
+	Elements    []LoopElement `json:"elements" binding:"required,min=1,dive"`
+}
@@ -1,9 +1,40 @@
 package models
 
+import "pcst-ai/backend/units"
+
+// SearchRequest may name the device's manufacturer and model so a reported
+// error code is looked up for that device only. LoopTag names a loop in the
+// ILD repository whose wiring the steps should follow.
 type SearchRequest struct {
-	Equipment string `json:"equipment" binding:"required"`
-	Problem   string `json:"problem" binding:"required"`
-	ErrorCode string `json:"error_code"`
+	Equipment    string `json:"equipment" binding:"required"`
+	Problem      string `json:"problem" binding:"required"`
+	ErrorCode    string `json:"errorCode"`
+	Manufacturer string `json:"manufacturer" binding:"max=100"`
+	Model        string `json:"model" binding:"max=100"`
+	LoopTag      string `json:"loopTag" binding:"max=64"`
+}
+
+// LegacySearchRequest is the body of the deprecated /api/search, which keeps
+// its original snake_case field names.
+type LegacySearchRequest struct {
+	Equipment    string `json:"equipment" binding:"required"`
+	Problem      string `json:"problem" binding:"required"`
+	ErrorCode    string `json:"error_code"`
+	Manufacturer string `json:"manufacturer" binding:"max=100"`
+	Model        string `json:"model" binding:"max=100"`
+	LoopTag      string `json:"loop_tag" binding:"max=64"`
+}
+
+// Request is the troubleshooting request the legacy body asks for.
+func (r LegacySearchRequest) Request() SearchRequest {
+	return SearchRequest{
+		Equipment:    r.Equipment,
+		Problem:      r.Problem,
+		ErrorCode:    r.ErrorCode,
+		Manufacturer: r.Manufacturer,
+		Model:        r.Model,
+		LoopTag:      r.LoopTag,
+	}
 }
 
 type VCRARequest struct {
@@ -14,10 +45,42 @@ type SafetyRequest struct {
 	Task string `json:"task" binding:"required"`
 }
 
+// CorrosionRequest uses pointers so that a legitimate zero (0°C, stagnant
+// flow) can be told apart from a missing field. Quantities accept either a
+// bare number in the canonical unit (°C, bar(a), m/s) or {"value", "unit"};
+// their physical ranges are checked after conversion. UnitSystem selects
+// the units the response is rendered in.
 type CorrosionRequest struct {
-	Material    string  `json:"material" binding:"required"`
-	Temperature float64 `json:"temperature" binding:"required"`
-	PH          float64 `json:"ph" binding:"required"`
-	Pressure    float64 `json:"pressure" binding:"required"`
-	Velocity    float64 `json:"velocity" binding:"required"`
+	Material    string          `json:"material" binding:"required,max=200"`
+	Temperature *units.Quantity `json:"temperature" binding:"required"`
+	PH          *float64        `json:"ph" binding:"required,gte=0,lte=14"`
+	Pressure    *units.Quantity `json:"pressure" binding:"required"`
+	Velocity    *units.Quantity `json:"velocity" binding:"required"`
+	UnitSystem  string          `json:"unitSystem" binding:"omitempty,oneof=metric imperial si us"`
+}
+
+// CorrosionLocation is one corrosion monitoring location in a batch
+// assessment: a CorrosionRequest with the caller's own ID for the location,
+// such as a CML number.
+type CorrosionLocation struct {
+	ID          string          `json:"id" binding:"required,max=100"`
+	Description string          `json:"description" binding:"max=500"`
+	Material    string          `json:"material" binding:"required,max=200"`
+	Temperature *units.Quantity `json:"temperature" binding:"required"`
+	PH          *float64        `json:"ph" binding:"required,gte=0,lte=14"`
+	Pressure    *units.Quantity `json:"pressure" binding:"required"`
+	Velocity    *units.Quantity `json:"velocity" binding:"required"`
+	UnitSystem  string          `json:"unitSystem" binding:"omitempty,oneof=metric imperial si us"`
+}
+
+// Request is the corrosion analysis request for the location.
+func (l CorrosionLocation) Request() CorrosionRequest {
+	return CorrosionRequest{
+		Material:    l.Material,
+		Temperature: l.Temperature,
+		PH:          l.PH,
+		Pressure:    l.Pressure,
+		Velocity:    l.Velocity,
+		UnitSystem:  l.UnitSystem,
+	}
 }
@@ -1,5 +1,7 @@
 package models
 
+import "pcst-ai/backend/units"
+
 type SearchResponse struct {
 	Success   bool             `json:"success"`
 	Equipment string           `json:"equipment"`
@@ -14,17 +16,57 @@ type ResponseSections struct {
 	EquipmentNotes string   `json:"equipment_notes"`
 }
 
+// TroubleshootingDetails is the /api/v1 troubleshooting result. The legacy
+// /api/search route wraps the same data in SearchResponse.
+type TroubleshootingDetails struct {
+	EquipmentID      string   `json:"equipmentId"`
+	KnowledgeApplied bool     `json:"knowledgeApplied"`
+	Equipment        string   `json:"equipment"`
+	Analysis         string   `json:"analysis"`
+	Causes           []string `json:"causes"`
+	Steps            []string `json:"steps"`
+	SafetyWarnings   []string `json:"safetyWarnings"`
+	EquipmentNotes   string   `json:"equipmentNotes"`
+	// KnownCodes are the vendor database entries matching the reported
+	// error code; they are documented facts, not model output.
+	KnownCodes []ErrorCode `json:"knownCodes"`
+	// Loop is the diagram of the requested loop tag, or null.
+	Loop      *Loop      `json:"loop"`
+	Citations []Citation `json:"citations"`
+	// Attributions cover every cause, step and safety warning.
+	Attributions []Attribution `json:"attributions"`
+}
+
 type VCRAResponse struct {
 	Success  bool        `json:"success"`
 	Response VCRADetails `json:"response"`
 }
 
 type VCRADetails struct {
-	RootCause  string   `json:"rootCause"`
-	RiskLevel  string   `json:"riskLevel"`
-	Confidence float64  `json:"confidence"`
-	Actions    []string `json:"actions"`
-	Timeline   []string `json:"timeline"`
+	RootCause  string         `json:"rootCause"`
+	RiskLevel  string         `json:"riskLevel"`
+	Confidence float64        `json:"confidence"`
+	Actions    []string       `json:"actions"`
+	Timeline   []TimelineStep `json:"timeline"`
+	Citations  []Citation     `json:"citations"`
+	// Attributions cover every action and timeline step.
+	Attributions []Attribution `json:"attributions"`
+}
+
+// TimelineStep is one recovery step. StartMinutes and EndMinutes are offsets
+// from the start of the incident and are only meaningful when Scheduled is
+// set; OpenEnded steps have no upper bound.
+type TimelineStep struct {
+	Sequence     int     `json:"sequence"`
+	Phase        string  `json:"phase"`
+	Window       string  `json:"window"`
+	StartMinutes float64 `json:"startMinutes"`
+	EndMinutes   float64 `json:"endMinutes"`
+	Scheduled    bool    `json:"scheduled"`
+	OpenEnded    bool    `json:"openEnded"`
+	Role         string  `json:"role"`
+	Action       string  `json:"action"`
+	Text         string  `json:"text"`
 }
 
 type SafetyResponse struct {
@@ -33,16 +75,29 @@ type SafetyResponse struct {
 }
 
 type SafetyDetails struct {
-	HazardLevel  string         `json:"hazardLevel"`
-	Hazards      []HazardDetail `json:"hazards"`
-	Mitigations  []string       `json:"mitigations"`
-	Standards    []string       `json:"standards"`
+	HazardLevel       string         `json:"hazardLevel"`
+	ResidualRiskLevel string         `json:"residualRiskLevel"`
+	RiskMatrix        string         `json:"riskMatrix"`
+	Hazards           []HazardDetail `json:"hazards"`
+	Mitigations       []string       `json:"mitigations"`
+	Standards         []string       `json:"standards"`
+	Citations         []Citation     `json:"citations"`
+	// Attributions cover every mitigation and standard.
+	Attributions []Attribution `json:"attributions"`
 }
 
 type HazardDetail struct {
-	Name        string `json:"name"`
-	Severity    string `json:"severity"`
-	Probability string `json:"probability"`
+	Name               string `json:"name"`
+	Severity           string `json:"severity"`
+	Probability        string `json:"probability"`
+	SeverityRating     int    `json:"severityRating"`
+	LikelihoodRating   int    `json:"likelihoodRating"`
+	InitialRisk        int    `json:"initialRisk"`
+	InitialRiskLevel   string `json:"initialRiskLevel"`
+	ResidualSeverity   int    `json:"residualSeverity"`
+	ResidualLikelihood int    `json:"residualLikelihood"`
+	ResidualRisk       int    `json:"residualRisk"`
+	ResidualRiskLevel  string `json:"residualRiskLevel"`
 }
 
 type CorrosionResponse struct {
@@ -50,10 +105,48 @@ type CorrosionResponse struct {
 	Response CorrosionDetails `json:"response"`
 }
 
+// CorrosionDetails is rendered in the unit system the caller asked for;
+// CorrosionRate is expressed in CorrosionRateUnit.
 type CorrosionDetails struct {
-	RiskLevel       string   `json:"riskLevel"`
-	CorrosionRate   float64  `json:"corrosionRate"`
-	Mechanisms      []string `json:"mechanisms"`
-	Recommendations []string `json:"recommendations"`
-	EstimatedLife   string   `json:"estimatedLife"`
+	RiskLevel         string            `json:"riskLevel"`
+	CorrosionRate     float64           `json:"corrosionRate"`
+	CorrosionRateUnit string            `json:"corrosionRateUnit"`
+	Mechanisms        []string          `json:"mechanisms"`
+	Recommendations   []string          `json:"recommendations"`
+	EstimatedLife     string            `json:"estimatedLife"`
+	UnitSystem        string            `json:"unitSystem"`
+	Conditions        ProcessConditions `json:"conditions"`
+	Citations         []Citation        `json:"citations"`
+	// Attributions cover every mechanism and recommendation.
+	Attributions []Attribution `json:"attributions"`
+}
+
+// CorrosionBatch is the outcome of a batch assessment. Locations are ranked
+// from the highest risk down, then by corrosion rate; those whose analysis
+// failed come last.
+type CorrosionBatch struct {
+	Total     int                  `json:"total"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Locations []CorrosionBatchItem `json:"locations"`
+}
+
+// CorrosionBatchItem is one location's assessment. Result is null and Error
+// says why when Status is failed.
+type CorrosionBatchItem struct {
+	Rank        int               `json:"rank"`
+	ID          string            `json:"id"`
+	Description string            `json:"description"`
+	Material    string            `json:"material"`
+	Status      string            `json:"status"`
+	Error       string            `json:"error"`
+	Result      *CorrosionDetails `json:"result"`
+}
+
+// ProcessConditions echoes the assessed conditions after normalization.
+type ProcessConditions struct {
+	Temperature units.Quantity `json:"temperature"`
+	PH          float64        `json:"ph"`
+	Pressure    units.Quantity `json:"pressure"`
+	Velocity    units.Quantity `json:"velocity"`
 }
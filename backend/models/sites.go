@@ -0,0 +1,12 @@
+package models
+
+// Site is a plant the service runs for. Organization, SafetyRules and
+// Standards are written into the analyzer prompts in place of fixed
+// company wording.
+type Site struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Organization string   `json:"organization"`
+	SafetyRules  []string `json:"safetyRules"`
+	Standards    []string `json:"standards"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+const (
+	RoleTechnician = "technician"
+	RoleEngineer   = "engineer"
+	RoleSupervisor = "supervisor"
+	RoleAdmin      = "admin"
+)
+
+// AllSites in a caller's sites grants every site.
+const AllSites = "*"
+
+// User is a local account. Accounts from an external identity provider are
+// not stored.
+type User struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Sites     []string  `json:"sites"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UserInput creates or replaces a local account. Password is required when
+// creating; on update an empty password keeps the current one. An account
+// with no sites may use none; AllSites grants every site.
+type UserInput struct {
+	Username string   `json:"username" binding:"required,max=100"`
+	Name     string   `json:"name" binding:"max=200"`
+	Role     string   `json:"role" binding:"required,oneof=technician engineer supervisor admin"`
+	Sites    []string `json:"sites" binding:"max=50"`
+	Password string   `json:"password" binding:"omitempty,min=8,max=72"`
+	Disabled bool     `json:"disabled"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Session is a signed-in caller. Token is sent back as a bearer token.
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      Principal `json:"user"`
+}
+
+// Principal is the authenticated caller of a request. Source is "local" for
+// local accounts and "oidc" for tokens from the configured issuer. Sites
+// are the sites the caller may use: none means none, and only AllSites or
+// the admin role grants every site.
+type Principal struct {
+	Subject  string   `json:"subject"`
+	Username string   `json:"username"`
+	Name     string   `json:"name"`
+	Role     string   `json:"role"`
+	Sites    []string `json:"sites"`
+	Source   string   `json:"source"`
+}
+
+const (
+	DecisionApproved = "approved"
+	DecisionRejected = "rejected"
+)
+
+// ApprovalInput is an engineer's sign-off on a safety assessment.
+type ApprovalInput struct {
+	Decision string `json:"decision" binding:"required,oneof=approved rejected"`
+	Comment  string `json:"comment" binding:"max=2000"`
+}
+
+type Approval struct {
+	AnalysisID string    `json:"analysisId"`
+	Decision   string    `json:"decision"`
+	Comment    string    `json:"comment"`
+	User       string    `json:"user"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,73 @@
+package openapi
+
+import (
+	"embed"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// swaggerUI holds the vendored swagger-ui-dist files the docs page loads, so
+// it works without reaching a CDN.
+//
+//go:embed swagger-ui
+var swaggerUI embed.FS
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+	specErr  error
+)
+
+func HandleSpec(c *gin.Context) {
+	specOnce.Do(func() {
+		specJSON, specErr = Build().JSON()
+	})
+	if specErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": specErr.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", specJSON)
+}
+
+// docsUnavailablePage stands in for the docs page in a build made without
+// the Swagger UI files, which would otherwise render blank.
+const docsUnavailablePage = `<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>PCST-AI API</title></head>
+<body>
+  <p>Interactive documentation is not available in this build: the Swagger UI files were not vendored (run <code>go run ./cmd/swaggerui</code> in <code>backend</code> and rebuild).</p>
+  <p>The OpenAPI document is at <a href="openapi.json">openapi.json</a>.</p>
+</body>
+</html>
+`
+
+func HandleDocs(c *gin.Context) {
+	if _, err := swaggerUI.ReadFile("swagger-ui/swagger-ui-bundle.js"); err != nil {
+		c.Data(http.StatusServiceUnavailable, "text/html; charset=utf-8", []byte(docsUnavailablePage))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
+
+func HandleDocsScript(c *gin.Context) {
+	serveSwaggerUI(c, "swagger-ui-bundle.js", "text/javascript; charset=utf-8")
+}
+
+func HandleDocsStylesheet(c *gin.Context) {
+	serveSwaggerUI(c, "swagger-ui.css", "text/css; charset=utf-8")
+}
+
+func serveSwaggerUI(c *gin.Context, name, contentType string) {
+	data, err := swaggerUI.ReadFile("swagger-ui/" + name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": name + " is not vendored; run go run ./cmd/swaggerui"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"pcst-ai/backend/config"
+	"pcst-ai/backend/units"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(config.Duration(0))
+	quantityType = reflect.TypeOf(units.Quantity{})
+)
+
+// schemaBuilder turns Go types into schemas, registering every named struct
+// as a component so it is described once and referenced elsewhere.
+type schemaBuilder struct {
+	components map[string]*Schema
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: map[string]*Schema{}}
+}
+
+func (b *schemaBuilder) schemaFor(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	s := b.baseSchema(t)
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+func (b *schemaBuilder) baseSchema(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == durationType {
+		return &Schema{Type: "string", Description: "Go duration such as 30s or 12h"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := b.components[name]; !ok {
+			// Reserve the name first so recursive types terminate.
+			b.components[name] = &Schema{}
+			*b.components[name] = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	// interface{} and anything else is left unconstrained.
+	return &Schema{}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, skip := jsonName(f)
+		if skip {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := b.structSchema(embedded)
+				for k, v := range inner.Properties {
+					s.Properties[k] = v
+				}
+				s.Required = append(s.Required, inner.Required...)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := b.schemaFor(f.Type)
+		if isRequestQuantity(f) {
+			// Request quantities also accept a bare number in the canonical unit.
+			prop = &Schema{OneOf: []*Schema{{Type: "number", Format: "double"}, prop}}
+		}
+		if format := f.Tag.Get("format"); format != "" && prop.Ref == "" {
+			prop.Format = format
+		}
+		if desc := f.Tag.Get("description"); desc != "" {
+			if prop.Ref != "" {
+				// Siblings of $ref are ignored in OpenAPI 3.0.
+				prop = &Schema{Ref: prop.Ref}
+			} else {
+				prop.Description = desc
+			}
+		}
+		if applyBinding(prop, f.Tag.Get("binding")) {
+			// Pointers let a required field accept zero; they are not nullable.
+			prop.Nullable = false
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+
+	return s
+}
+
+func isRequestQuantity(f reflect.StructField) bool {
+	t := f.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == quantityType && f.Tag.Get("binding") != ""
+}
+
+func jsonName(f reflect.StructField) (name string, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	return strings.Split(tag, ",")[0], false
+}
+
+// applyBinding maps gin/validator binding rules onto schema constraints and
+// reports whether the field is required.
+func applyBinding(s *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "min", "gte":
+			setLowerBound(s, value, false)
+		case "gt":
+			setLowerBound(s, value, true)
+		case "max", "lte":
+			setUpperBound(s, value, false)
+		case "lt":
+			setUpperBound(s, value, true)
+		case "len":
+			setLowerBound(s, value, false)
+			setUpperBound(s, value, false)
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				s.Enum = append(s.Enum, enumValue(s, v))
+			}
+		case "email":
+			s.Format = "email"
+		case "url":
+			s.Format = "uri"
+		}
+	}
+	return required
+}
+
+func setLowerBound(s *Schema, value string, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		i := int(n)
+		s.MinLength = &i
+	case "array":
+		i := int(n)
+		s.MinItems = &i
+	default:
+		s.Minimum = &n
+		s.ExclusiveMinimum = exclusive
+	}
+}
+
+func setUpperBound(s *Schema, value string, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		i := int(n)
+		s.MaxLength = &i
+	case "array":
+		i := int(n)
+		s.MaxItems = &i
+	default:
+		s.Maximum = &n
+		s.ExclusiveMaximum = exclusive
+	}
+}
+
+func enumValue(s *Schema, v string) interface{} {
+	switch s.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
@@ -0,0 +1,325 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"pcst-ai/backend/auth"
+	"pcst-ai/backend/config"
+	"pcst-ai/backend/models"
+)
+
+//go:generate go run ../cmd/openapi -out openapi.json
+
+const Version = "3.0.3"
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*PathItem `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type PathItem struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Permission  string                `json:"x-permission,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Operation documents one route registered by the router, using gin's path
+// syntax. Request is the body bound by the handler, or nil when there is
+// none. Response is the type carried in Envelope.Data, or the whole body
+// when Raw is set. Query lists optional query string parameters. Request is
+// sent as JSON unless RequestType says otherwise; Accepts lists further
+// request content types, such as text/csv. Routes need a bearer token
+// unless Public is set; Permission names the one the caller's role must
+// grant, if any.
+type Operation struct {
+	Method      string
+	Path        string
+	ID          string
+	Summary     string
+	Tag         string
+	Query       []string
+	Request     interface{}
+	RequestType string
+	Accepts     []string
+	Response    interface{}
+	ContentType string
+	Raw         bool
+	Deprecated  bool
+	Public      bool
+	Permission  auth.Permission
+}
+
+type LegacyEquipmentResponse struct {
+	Equipment []string `json:"equipment"`
+}
+
+type LegacyErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// Operations lists every route the router registers. cmd/openapi -check
+// fails when this list and the router disagree.
+var Operations = []Operation{
+	{Method: http.MethodPost, Path: "/api/v1/auth/login", ID: "login", Summary: "Sign in with a local account and get a bearer token", Tag: "Auth", Request: models.LoginRequest{}, Response: models.Session{}, Public: true},
+	{Method: http.MethodGet, Path: "/api/v1/auth/me", ID: "getMe", Summary: "The authenticated caller", Tag: "Auth", Response: models.Principal{}},
+	{Method: http.MethodGet, Path: "/api/v1/sites", ID: "listSites", Summary: "List the sites the caller may use", Tag: "Auth", Response: []models.Site{}},
+	{Method: http.MethodGet, Path: "/api/v1/users", ID: "listUsers", Summary: "List local accounts", Tag: "Auth", Response: []models.User{}, Permission: auth.ManageUsers},
+	{Method: http.MethodPost, Path: "/api/v1/users", ID: "createUser", Summary: "Create a local account", Tag: "Auth", Request: models.UserInput{}, Response: models.User{}, Permission: auth.ManageUsers},
+	{Method: http.MethodPut, Path: "/api/v1/users/:id", ID: "updateUser", Summary: "Replace a local account; an empty password keeps the current one", Tag: "Auth", Request: models.UserInput{}, Response: models.User{}, Permission: auth.ManageUsers},
+	{Method: http.MethodDelete, Path: "/api/v1/users/:id", ID: "deleteUser", Summary: "Delete a local account", Tag: "Auth", Response: models.User{}, Permission: auth.ManageUsers},
+	{Method: http.MethodPost, Path: "/api/v1/troubleshooting/analyze", ID: "analyzeTroubleshooting", Summary: "Troubleshoot a process control equipment problem", Tag: "Troubleshooting", Request: models.SearchRequest{}, Response: models.TroubleshootingDetails{}, Permission: auth.Analyze},
+	{Method: http.MethodGet, Path: "/api/v1/equipment", ID: "listEquipment", Summary: "List catalog equipment, optionally filtered by category and search text", Tag: "Equipment", Query: []string{"category", "q"}, Response: []models.Equipment{}},
+	{Method: http.MethodPost, Path: "/api/v1/equipment", ID: "createEquipment", Summary: "Add equipment to the catalog", Tag: "Equipment", Request: models.EquipmentInput{}, Response: models.Equipment{}, Permission: auth.ManageReference},
+	{Method: http.MethodGet, Path: "/api/v1/equipment/:id", ID: "getEquipment", Summary: "Get one catalog entry", Tag: "Equipment", Response: models.Equipment{}},
+	{Method: http.MethodPut, Path: "/api/v1/equipment/:id", ID: "updateEquipment", Summary: "Replace a catalog entry", Tag: "Equipment", Request: models.EquipmentInput{}, Response: models.Equipment{}, Permission: auth.ManageReference},
+	{Method: http.MethodDelete, Path: "/api/v1/equipment/:id", ID: "deleteEquipment", Summary: "Remove a catalog entry", Tag: "Equipment", Response: models.Equipment{}, Permission: auth.ManageReference},
+	{Method: http.MethodGet, Path: "/api/v1/equipment/:id/knowledge", ID: "getEquipmentKnowledge", Summary: "Get the knowledge pack injected into troubleshooting prompts", Tag: "Equipment", Response: models.KnowledgePack{}},
+	{Method: http.MethodPut, Path: "/api/v1/equipment/:id/knowledge", ID: "putEquipmentKnowledge", Summary: "Replace an equipment knowledge pack", Tag: "Equipment", Request: models.KnowledgePack{}, Response: models.KnowledgePack{}, Permission: auth.ManageReference},
+	{Method: http.MethodDelete, Path: "/api/v1/equipment/:id/knowledge", ID: "deleteEquipmentKnowledge", Summary: "Clear an equipment knowledge pack", Tag: "Equipment", Response: models.KnowledgePack{}, Permission: auth.ManageReference},
+	{Method: http.MethodGet, Path: "/api/v1/error-codes", ID: "listErrorCodes", Summary: "List vendor error codes, or look one up when code is given", Tag: "Error codes", Query: []string{"manufacturer", "q", "code", "model"}, Response: []models.ErrorCode{}},
+	{Method: http.MethodPost, Path: "/api/v1/error-codes/import", ID: "importErrorCodes", Summary: "Import vendor error codes from a JSON array or CSV", Tag: "Error codes", Request: []models.ErrorCodeInput{}, Accepts: []string{"text/csv"}, Response: models.ImportResult{}, Permission: auth.ManageReference},
+	{Method: http.MethodDelete, Path: "/api/v1/error-codes/:id", ID: "deleteErrorCode", Summary: "Remove a vendor error code", Tag: "Error codes", Response: models.ErrorCode{}, Permission: auth.ManageReference},
+	{Method: http.MethodGet, Path: "/api/v1/loops", ID: "listLoops", Summary: "List instrument loop diagrams, optionally filtered by search text", Tag: "Loop diagrams", Query: []string{"q"}, Response: []models.Loop{}},
+	{Method: http.MethodPost, Path: "/api/v1/loops/import", ID: "importLoops", Summary: "Import loop diagrams from a JSON array or CSV with one row per loop element", Tag: "Loop diagrams", Request: []models.LoopInput{}, Accepts: []string{"text/csv"}, Response: models.ImportResult{}, Permission: auth.ManageReference},
+	{Method: http.MethodGet, Path: "/api/v1/loops/:tag", ID: "getLoop", Summary: "Get the wiring path of a loop tag", Tag: "Loop diagrams", Response: models.Loop{}},
+	{Method: http.MethodDelete, Path: "/api/v1/loops/:tag", ID: "deleteLoop", Summary: "Remove a loop diagram", Tag: "Loop diagrams", Response: models.Loop{}, Permission: auth.ManageReference},
+	{Method: http.MethodGet, Path: "/api/v1/documents", ID: "listDocuments", Summary: "List ingested manuals, procedures and bulletins", Tag: "Documents", Response: []models.Document{}},
+	{Method: http.MethodPost, Path: "/api/v1/documents", ID: "uploadDocument", Summary: "Ingest a PDF, Markdown or text document into the retrieval index", Tag: "Documents", Request: models.DocumentUpload{}, RequestType: "multipart/form-data", Response: models.Document{}, Permission: auth.ManageReference},
+	{Method: http.MethodGet, Path: "/api/v1/documents/search", ID: "searchDocuments", Summary: "Search the retrieval index", Tag: "Documents", Query: []string{"q", "limit"}, Response: []models.Passage{}},
+	{Method: http.MethodGet, Path: "/api/v1/documents/:id", ID: "getDocument", Summary: "Get an ingested document", Tag: "Documents", Response: models.Document{}},
+	{Method: http.MethodDelete, Path: "/api/v1/documents/:id", ID: "deleteDocument", Summary: "Remove a document from the retrieval index", Tag: "Documents", Response: models.Document{}, Permission: auth.ManageReference},
+	{Method: http.MethodGet, Path: "/api/v1/history", ID: "listHistory", Summary: "List and full-text search past analyses with filters and cursor pagination", Tag: "History", Query: []string{"type", "equipment", "risk", "user", "site", "from", "to", "q", "sort", "order", "cursor", "limit"}, Response: models.HistoryPage{}, Permission: auth.Analyze},
+	{Method: http.MethodGet, Path: "/api/v1/history/:id", ID: "getHistory", Summary: "Get a past analysis with its request, prompt, raw output and result", Tag: "History", Response: models.AnalysisRecord{}, Permission: auth.Analyze},
+	{Method: http.MethodGet, Path: "/api/v1/history/:id/feedback", ID: "listFeedback", Summary: "List the feedback given on an analysis", Tag: "History", Response: []models.Feedback{}, Permission: auth.Analyze},
+	{Method: http.MethodPost, Path: "/api/v1/history/:id/feedback", ID: "createFeedback", Summary: "Rate an analysis and record which items were right and how the problem was actually fixed", Tag: "History", Request: models.FeedbackInput{}, Response: models.Feedback{}, Permission: auth.Analyze},
+	{Method: http.MethodGet, Path: "/api/v1/feedback/report", ID: "feedbackReport", Summary: "Answer accuracy per analyzer and equipment over time", Tag: "History", Query: []string{"type", "equipment", "site", "from", "to", "interval"}, Response: models.AccuracyReport{}, Permission: auth.ViewReports},
+	{Method: http.MethodPost, Path: "/api/v1/history/:id/approval", ID: "approveSafety", Summary: "Approve or reject a safety assessment", Tag: "History", Request: models.ApprovalInput{}, Response: models.Approval{}, Permission: auth.ApproveSafety},
+	{Method: http.MethodGet, Path: "/api/v1/audit/verify", ID: "verifyAudit", Summary: "Walk the audit hash chain and report the first entry that was tampered with", Tag: "Audit", Response: models.AuditVerification{}, Permission: auth.ViewAudit},
+	{Method: http.MethodGet, Path: "/api/v1/audit/export", ID: "exportAudit", Summary: "Download audit entries as JSON lines or CSV", Tag: "Audit", Query: []string{"from", "to", "format"}, ContentType: "application/x-ndjson", Raw: true, Permission: auth.ViewAudit},
+	{Method: http.MethodPost, Path: "/api/v1/vcra/analyze", ID: "analyzeVCRA", Summary: "Analyze control room logs for an incident", Tag: "VCRA", Request: models.VCRARequest{}, Response: models.VCRADetails{}, Permission: auth.Analyze},
+	{Method: http.MethodPost, Path: "/api/v1/safety/analyze", ID: "analyzeSafety", Summary: "Assess the hazards of a job task", Tag: "Safety", Request: models.SafetyRequest{}, Response: models.SafetyDetails{}, Permission: auth.Analyze},
+	{Method: http.MethodPost, Path: "/api/v1/corrosion/analyze", ID: "analyzeCorrosion", Summary: "Assess corrosion risk for process conditions", Tag: "Corrosion", Request: models.CorrosionRequest{}, Response: models.CorrosionDetails{}, Permission: auth.Analyze},
+	{Method: http.MethodPost, Path: "/api/v1/corrosion/batch", ID: "analyzeCorrosionBatch", Summary: "Assess many monitoring locations from a JSON array or CSV and rank them by risk; format=csv downloads the ranking as CSV", Tag: "Corrosion", Request: []models.CorrosionLocation{}, Accepts: []string{"text/csv"}, Query: []string{"format"}, Response: models.CorrosionBatch{}, Permission: auth.Analyze},
+	{Method: http.MethodGet, Path: "/api/v1/config", ID: "getConfig", Summary: "Show the effective configuration with secrets redacted", Tag: "Meta", Response: config.Config{}, Permission: auth.ViewConfig},
+	{Method: http.MethodGet, Path: "/api/v1/version", ID: "getVersion", Summary: "Build information and the prompt versions analyses record", Tag: "Meta", Response: models.BuildInfo{}, Public: true},
+	{Method: http.MethodGet, Path: "/api/v1/openapi.json", ID: "getOpenAPI", Summary: "This OpenAPI document", Tag: "Meta", Response: map[string]interface{}{}, Raw: true, Public: true},
+	{Method: http.MethodGet, Path: "/api/v1/docs", ID: "getDocs", Summary: "Interactive API documentation", Tag: "Meta", ContentType: "text/html", Raw: true, Public: true},
+	{Method: http.MethodGet, Path: "/api/v1/docs/swagger-ui-bundle.js", ID: "getDocsScript", Summary: "Swagger UI script the documentation page loads", Tag: "Meta", ContentType: "text/javascript", Raw: true, Public: true},
+	{Method: http.MethodGet, Path: "/api/v1/docs/swagger-ui.css", ID: "getDocsStylesheet", Summary: "Swagger UI stylesheet the documentation page loads", Tag: "Meta", ContentType: "text/css", Raw: true, Public: true},
+
+	{Method: http.MethodPost, Path: "/api/search", ID: "legacySearch", Summary: "Deprecated: use /api/v1/troubleshooting/analyze", Tag: "Legacy", Request: models.LegacySearchRequest{}, Response: models.SearchResponse{}, Raw: true, Deprecated: true, Permission: auth.Analyze},
+	{Method: http.MethodGet, Path: "/api/equipment", ID: "legacyListEquipment", Summary: "Deprecated: use /api/v1/equipment", Tag: "Legacy", Response: LegacyEquipmentResponse{}, Raw: true, Deprecated: true, Permission: auth.Analyze},
+	{Method: http.MethodPost, Path: "/api/vcra/analyze", ID: "legacyAnalyzeVCRA", Summary: "Deprecated: use /api/v1/vcra/analyze", Tag: "Legacy", Request: models.VCRARequest{}, Response: models.VCRAResponse{}, Raw: true, Deprecated: true, Permission: auth.Analyze},
+	{Method: http.MethodPost, Path: "/api/safety/analyze", ID: "legacyAnalyzeSafety", Summary: "Deprecated: use /api/v1/safety/analyze", Tag: "Legacy", Request: models.SafetyRequest{}, Response: models.SafetyResponse{}, Raw: true, Deprecated: true, Permission: auth.Analyze},
+	{Method: http.MethodPost, Path: "/api/corrosion/analyze", ID: "legacyAnalyzeCorrosion", Summary: "Deprecated: use /api/v1/corrosion/analyze", Tag: "Legacy", Request: models.CorrosionRequest{}, Response: models.CorrosionResponse{}, Raw: true, Deprecated: true, Permission: auth.Analyze},
+
+	{Method: http.MethodGet, Path: "/health", ID: "health", Summary: "Liveness check; same as /health/live", Tag: "Meta", Response: HealthResponse{}, Raw: true, Public: true},
+	{Method: http.MethodGet, Path: "/health/live", ID: "live", Summary: "Liveness check", Tag: "Meta", Response: HealthResponse{}, Raw: true, Public: true},
+	{Method: http.MethodGet, Path: "/health/ready", ID: "ready", Summary: "Readiness check of the provider, database and document index; 503 when any fails", Tag: "Meta", Response: models.Readiness{}, Raw: true, Public: true},
+	{Method: http.MethodGet, Path: "/metrics", ID: "metrics", Summary: "Prometheus metrics", Tag: "Meta", ContentType: "text/plain", Raw: true, Public: true},
+}
+
+// Build derives the OpenAPI document from Operations and the models they
+// reference.
+func Build() *Document {
+	b := newSchemaBuilder()
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "PCST-AI API",
+			Version:     "v1",
+			Description: "Process Control System Technicians' AI Platform. Routes under /api/v1 wrap results in a common envelope; unversioned /api routes are deprecated.",
+		},
+		Paths: map[string]map[string]*PathItem{},
+	}
+
+	envelope := b.schemaFor(reflect.TypeOf(models.Envelope{}))
+	errorBody := &MediaType{Schema: envelope}
+	legacyErrorBody := &MediaType{Schema: b.schemaFor(reflect.TypeOf(LegacyErrorResponse{}))}
+
+	for _, op := range Operations {
+		item := &PathItem{
+			OperationID: op.ID,
+			Summary:     op.Summary,
+			Tags:        []string{op.Tag},
+			Deprecated:  op.Deprecated,
+			Responses:   map[string]*Response{},
+		}
+
+		path, params := openAPIPath(op.Path)
+		for _, name := range params {
+			item.Parameters = append(item.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		for _, name := range op.Query {
+			item.Parameters = append(item.Parameters, &Parameter{Name: name, In: "query", Schema: &Schema{Type: "string"}})
+		}
+
+		if op.Request != nil {
+			requestType := op.RequestType
+			if requestType == "" {
+				requestType = "application/json"
+			}
+			item.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					requestType: {Schema: b.schemaFor(reflect.TypeOf(op.Request))},
+				},
+			}
+			for _, contentType := range op.Accepts {
+				item.RequestBody.Content[contentType] = &MediaType{Schema: &Schema{Type: "string"}}
+			}
+		}
+
+		ok := &Response{Description: "Success"}
+		switch {
+		case op.ContentType != "":
+			ok.Content = map[string]*MediaType{op.ContentType: {Schema: &Schema{Type: "string"}}}
+		case op.Raw:
+			ok.Content = map[string]*MediaType{"application/json": {Schema: b.schemaFor(reflect.TypeOf(op.Response))}}
+		default:
+			ok.Content = map[string]*MediaType{"application/json": {Schema: envelopeWith(b, op.Response)}}
+		}
+		item.Responses["200"] = ok
+
+		errBody := errorBody
+		if op.Raw {
+			errBody = legacyErrorBody
+		}
+		if op.Request != nil {
+			item.Responses["400"] = &Response{Description: "Invalid request", Content: map[string]*MediaType{"application/json": errBody}}
+			item.Responses["500"] = &Response{Description: "Request failed", Content: map[string]*MediaType{"application/json": errBody}}
+		}
+		if !op.Public {
+			// Authenticated routes run for the site named by this header.
+			item.Parameters = append(item.Parameters, &Parameter{Name: "X-Site", In: "header", Schema: &Schema{Type: "string"}})
+			item.Security = []map[string][]string{{"bearerAuth": {}}}
+			item.Responses["401"] = &Response{Description: "Missing or invalid bearer token", Content: map[string]*MediaType{"application/json": errBody}}
+		}
+		if op.Permission != "" {
+			item.Permission = string(op.Permission)
+			forbidden := "Role lacks the " + string(op.Permission) + " permission"
+			if op.Permission == auth.ManageReference {
+				forbidden += ", or the data is shared with a site the caller may not use"
+			}
+			item.Responses["403"] = &Response{Description: forbidden, Content: map[string]*MediaType{"application/json": errBody}}
+		}
+		if len(params) > 0 {
+			item.Responses["404"] = &Response{Description: "Not found", Content: map[string]*MediaType{"application/json": errBody}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = item
+	}
+
+	doc.Components.Schemas = b.components
+	doc.Components.SecuritySchemes = map[string]*SecurityScheme{
+		"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+	}
+	return doc
+}
+
+// openAPIPath converts gin's "/items/:id" into "/items/{id}" and returns the
+// parameter names.
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			name := seg[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// envelopeWith describes an Envelope whose data field holds the given type.
+func envelopeWith(b *schemaBuilder, data interface{}) *Schema {
+	base := b.schemaFor(reflect.TypeOf(models.Envelope{}))
+	env := *b.components[strings.TrimPrefix(base.Ref, "#/components/schemas/")]
+
+	props := make(map[string]*Schema, len(env.Properties))
+	for k, v := range env.Properties {
+		props[k] = v
+	}
+	props["data"] = b.schemaFor(reflect.TypeOf(data))
+	env.Properties = props
+	env.Required = []string{"success", "data", "requestId", "metadata"}
+	return &env
+}
+
+// JSON renders the document with stable formatting so the committed copy
+// can be compared byte for byte.
+func (d *Document) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// RouteKeys returns "METHOD path" for every documented operation, sorted.
+func RouteKeys() []string {
+	keys := make([]string, 0, len(Operations))
+	for _, op := range Operations {
+		keys = append(keys, op.Method+" "+op.Path)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"log/slog"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"pcst-ai/backend/auth"
+	"pcst-ai/backend/config"
+	"pcst-ai/backend/handlers"
+	"pcst-ai/backend/metrics"
+	"pcst-ai/backend/middleware"
+	"pcst-ai/backend/openapi"
+)
+
+// New builds the engine with every route cfg enables. Routes added here
+// must also be described in openapi.Operations.
+func New(cfg *config.Config) *gin.Engine {
+	r := gin.New()
+	// An empty list trusts no proxy, so the peer address is the client.
+	r.SetTrustedProxies(cfg.Server.TrustedProxies)
+	r.Use(gin.Recovery(), middleware.RequestID(), middleware.Tracing(), middleware.Logger(slog.Default()), middleware.Metrics())
+
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.Server.CORSOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader, handlers.SiteHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{middleware.RequestIDHeader, "Deprecation", "Link"},
+		AllowCredentials: true,
+	}))
+
+	v1 := r.Group("/api/v1")
+	{
+		v1.POST("/auth/login", handlers.HandleLoginV1)
+		v1.GET("/version", handlers.HandleVersionV1)
+		v1.GET("/openapi.json", openapi.HandleSpec)
+		if cfg.Features.APIDocs {
+			v1.GET("/docs", openapi.HandleDocs)
+			v1.GET("/docs/swagger-ui-bundle.js", openapi.HandleDocsScript)
+			v1.GET("/docs/swagger-ui.css", openapi.HandleDocsStylesheet)
+		}
+	}
+
+	analyze := handlers.Require(auth.Analyze)
+	manage := handlers.Require(auth.ManageReference)
+
+	secured := v1.Group("", handlers.Authenticate(), handlers.ResolveSite())
+	{
+		secured.GET("/auth/me", handlers.HandleMeV1)
+		secured.GET("/sites", handlers.HandleListSitesV1)
+		secured.GET("/config", handlers.Require(auth.ViewConfig), handlers.HandleConfigV1)
+		secured.POST("/troubleshooting/analyze", analyze, handlers.HandleTroubleshootingV1)
+		secured.GET("/equipment", handlers.HandleListEquipmentV1)
+		secured.POST("/equipment", manage, handlers.HandleCreateEquipmentV1)
+		secured.GET("/equipment/:id", handlers.HandleGetEquipmentV1)
+		secured.PUT("/equipment/:id", manage, handlers.HandleUpdateEquipmentV1)
+		secured.DELETE("/equipment/:id", manage, handlers.HandleDeleteEquipmentV1)
+		secured.GET("/equipment/:id/knowledge", handlers.HandleGetKnowledgeV1)
+		secured.PUT("/equipment/:id/knowledge", manage, handlers.HandlePutKnowledgeV1)
+		secured.DELETE("/equipment/:id/knowledge", manage, handlers.HandleDeleteKnowledgeV1)
+		secured.GET("/error-codes", handlers.HandleListErrorCodesV1)
+		secured.POST("/error-codes/import", manage, handlers.HandleImportErrorCodesV1)
+		secured.DELETE("/error-codes/:id", manage, handlers.HandleDeleteErrorCodeV1)
+		secured.GET("/loops", handlers.HandleListLoopsV1)
+		secured.POST("/loops/import", manage, handlers.HandleImportLoopsV1)
+		secured.GET("/loops/:tag", handlers.HandleGetLoopV1)
+		secured.DELETE("/loops/:tag", manage, handlers.HandleDeleteLoopV1)
+		secured.GET("/documents", handlers.HandleListDocumentsV1)
+		secured.POST("/documents", manage, handlers.HandleUploadDocumentV1)
+		secured.GET("/documents/search", handlers.HandleSearchDocumentsV1)
+		secured.GET("/documents/:id", handlers.HandleGetDocumentV1)
+		secured.DELETE("/documents/:id", manage, handlers.HandleDeleteDocumentV1)
+		secured.GET("/history", analyze, handlers.HandleListHistoryV1)
+		secured.GET("/history/:id", analyze, handlers.HandleGetHistoryV1)
+		secured.GET("/history/:id/feedback", analyze, handlers.HandleListFeedbackV1)
+		secured.POST("/history/:id/feedback", analyze, handlers.HandleCreateFeedbackV1)
+		secured.POST("/history/:id/approval", handlers.Require(auth.ApproveSafety), handlers.HandleApproveSafetyV1)
+		secured.GET("/feedback/report", handlers.Require(auth.ViewReports), handlers.HandleFeedbackReportV1)
+		secured.GET("/audit/verify", handlers.Require(auth.ViewAudit), handlers.HandleVerifyAuditV1)
+		secured.GET("/audit/export", handlers.Require(auth.ViewAudit), handlers.HandleExportAuditV1)
+		secured.POST("/vcra/analyze", analyze, handlers.HandleVCRAV1)
+		secured.POST("/safety/analyze", analyze, handlers.HandleSafetyV1)
+		secured.POST("/corrosion/analyze", analyze, handlers.HandleCorrosionV1)
+		secured.POST("/corrosion/batch", analyze, handlers.HandleCorrosionBatchV1)
+	}
+
+	users := secured.Group("/users", handlers.Require(auth.ManageUsers))
+	{
+		users.GET("", handlers.HandleListUsersV1)
+		users.POST("", handlers.HandleCreateUserV1)
+		users.PUT("/:id", handlers.HandleUpdateUserV1)
+		users.DELETE("/:id", handlers.HandleDeleteUserV1)
+	}
+
+	// Deprecated: the unversioned routes keep their original response shapes
+	// for existing clients. New integrations should use /api/v1.
+	if cfg.Features.LegacyRoutes {
+		api := r.Group("/api", handlers.Authenticate(), handlers.ResolveSite(), analyze)
+		api.POST("/search", handlers.Deprecated("/api/v1/troubleshooting/analyze"), handlers.HandleSearch)
+		api.GET("/equipment", handlers.Deprecated("/api/v1/equipment"), handlers.HandleGetEquipment)
+		api.POST("/vcra/analyze", handlers.Deprecated("/api/v1/vcra/analyze"), handlers.HandleVCRA)
+		api.POST("/safety/analyze", handlers.Deprecated("/api/v1/safety/analyze"), handlers.HandleSafety)
+		api.POST("/corrosion/analyze", handlers.Deprecated("/api/v1/corrosion/analyze"), handlers.HandleCorrosion)
+	}
+
+	// /health predates the split into liveness and readiness and stays a
+	// liveness check.
+	r.GET("/health", handlers.HandleLive)
+	r.GET("/health/live", handlers.HandleLive)
+	r.GET("/health/ready", handlers.HandleReady)
+	if cfg.Features.Metrics {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
+	return r
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// citationMarker matches the source labels the model is asked to append to
+// items, such as "[D1]", "[K1, D2]" or "[E1; L1]".
+var (
+	citationMarker = regexp.MustCompile(`\s*\[([A-Z]\d+(?:\s*[,;]\s*[A-Z]\d+)*)\]`)
+	citationLabel  = regexp.MustCompile(`[A-Z]\d+`)
+)
+
+// SplitCitations removes citation markers from text and returns the labels
+// they named, in order of first appearance.
+func SplitCitations(text string) (string, []string) {
+	var labels []string
+	seen := map[string]bool{}
+	for _, m := range citationMarker.FindAllStringSubmatch(text, -1) {
+		for _, label := range citationLabel.FindAllString(m[1], -1) {
+			if !seen[label] {
+				seen[label] = true
+				labels = append(labels, label)
+			}
+		}
+	}
+	return strings.TrimSpace(citationMarker.ReplaceAllString(text, "")), labels
+}
+
+// StripCitations removes citation markers from free text.
+func StripCitations(text string) string {
+	clean, _ := SplitCitations(text)
+	return clean
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+const embeddingBatchSize = 100
+
+// GeminiEmbedder computes text embeddings with a Gemini embedding model.
+type GeminiEmbedder struct {
+	APIKey string
+	Model  string
+}
+
+func (e GeminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.APIKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY not configured")
+	}
+
+	client, err := genai.NewClient(ctx, option.WithAPIKey(e.APIKey))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	model := client.EmbeddingModel(e.Model)
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(texts))
+		batch := model.NewBatch()
+		for _, text := range texts[start:end] {
+			batch.AddContent(genai.Text(text))
+		}
+
+		resp, err := model.BatchEmbedContents(ctx, batch)
+		if err != nil {
+			return nil, hideKey(err, e.APIKey)
+		}
+		if len(resp.Embeddings) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(resp.Embeddings))
+		}
+		for _, emb := range resp.Embeddings {
+			vectors = append(vectors, emb.Values)
+		}
+	}
+	return vectors, nil
+}
@@ -2,8 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
@@ -11,11 +12,12 @@ import (
 
 type GeminiService struct {
 	client *genai.Client
+	apiKey string
+	model  string
 	ctx    context.Context
 }
 
-func NewGeminiService(ctx context.Context) (*GeminiService, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
+func NewGeminiService(ctx context.Context, apiKey, model string) (*GeminiService, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("GEMINI_API_KEY not configured")
 	}
@@ -27,23 +29,46 @@ func NewGeminiService(ctx context.Context) (*GeminiService, error) {
 
 	return &GeminiService{
 		client: client,
+		apiKey: apiKey,
+		model:  model,
 		ctx:    ctx,
 	}, nil
 }
 
-func (g *GeminiService) Generate(prompt string) (string, error) {
-	model := g.client.GenerativeModel("gemini-2.5-flash")
+// Usage is the number of tokens one generation consumed.
+type Usage struct {
+	PromptTokens int
+	OutputTokens int
+}
+
+func (g *GeminiService) Generate(prompt string) (string, Usage, error) {
+	model := g.client.GenerativeModel(g.model)
 
 	resp, err := model.GenerateContent(g.ctx, genai.Text(prompt))
 	if err != nil {
-		return "", err
+		return "", Usage{}, hideKey(err, g.apiKey)
 	}
 
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no response generated")
+	var usage Usage
+	if resp.UsageMetadata != nil {
+		usage.PromptTokens = int(resp.UsageMetadata.PromptTokenCount)
+		usage.OutputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
 	}
 
-	return fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0]), nil
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", usage, fmt.Errorf("no response generated")
+	}
+
+	return fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0]), usage, nil
+}
+
+// Ping looks the model up without generating anything, to check the key
+// and that the provider can be reached.
+func (g *GeminiService) Ping() error {
+	if _, err := g.client.GenerativeModel(g.model).Info(g.ctx); err != nil {
+		return hideKey(err, g.apiKey)
+	}
+	return nil
 }
 
 func (g *GeminiService) Close() {
@@ -51,3 +76,12 @@ func (g *GeminiService) Close() {
 		g.client.Close()
 	}
 }
+
+// hideKey removes the API key from err. Transport errors quote the request
+// URL, which carries the key, and error messages reach logs and clients.
+func hideKey(err error, apiKey string) error {
+	if apiKey == "" || !strings.Contains(err.Error(), apiKey) {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), apiKey, "[redacted]"))
+}
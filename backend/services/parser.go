@@ -1,13 +1,16 @@
 package services
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"pcst-ai/backend/models"
 )
 
 // TODO: make this readable
-func ParseTroubleshootingResponse(text string) (string, []string, []string, []string, string) {
+func ParseTroubleshootingResponse(text string) (string, []string, []string, []string, string, []string) {
 	var analysis string
 	var causes []string
 	var steps []string
@@ -65,15 +68,33 @@ func ParseTroubleshootingResponse(text string) (string, []string, []string, []st
 		}
 	}
 
-	return analysis, causes, steps, safetyWarnings, equipmentNotes
+	var warnings []string
+	warnings = missing(warnings, analysis == "", "ANALYSIS")
+	warnings = missing(warnings, len(causes) == 0, "POSSIBLE CAUSES")
+	warnings = missing(warnings, len(steps) == 0, "TROUBLESHOOTING STEPS")
+	warnings = missing(warnings, len(safetyWarnings) == 0, "SAFETY WARNINGS")
+
+	return analysis, causes, steps, safetyWarnings, equipmentNotes, warnings
 }
 
-func ParseVCRAResponse(text string) (string, string, float64, []string, []string) {
+// Every parser also returns warnings naming what it could not find in the
+// answer, so poorly formatted answers show up in the log.
+
+// missing adds a warning for section when absent.
+func missing(warnings []string, absent bool, section string) []string {
+	if absent {
+		warnings = append(warnings, section+" section missing")
+	}
+	return warnings
+}
+
+func ParseVCRAResponse(text string) (string, string, float64, []string, []models.TimelineStep, []string) {
 	rootCause := ""
 	riskLevel := "MEDIUM"
 	confidence := 0.75
+	var riskFound, confidenceFound bool
 	var actions []string
-	var timeline []string
+	var timeline []models.TimelineStep
 
 	lines := strings.Split(text, "\n")
 	var currentSection string
@@ -95,6 +116,7 @@ func ParseVCRAResponse(text string) (string, string, float64, []string, []string
 			parts := strings.SplitN(line, ":", 2)
 			if len(parts) > 1 {
 				level := strings.ToUpper(strings.TrimSpace(parts[1]))
+				riskFound = level != ""
 				if strings.Contains(level, "HIGH") {
 					riskLevel = "HIGH"
 				} else if strings.Contains(level, "LOW") {
@@ -111,6 +133,7 @@ func ParseVCRAResponse(text string) (string, string, float64, []string, []string
 				confStr := strings.TrimSpace(parts[1])
 				confStr = strings.Trim(confStr, "%")
 				if val, err := strconv.ParseFloat(confStr, 64); err == nil {
+					confidenceFound = true
 					if val > 1 {
 						confidence = val / 100
 					} else {
@@ -132,16 +155,38 @@ func ParseVCRAResponse(text string) (string, string, float64, []string, []string
 		} else if currentSection == "actions" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "•") || regexp.MustCompile(`^\d+\.`).MatchString(line)) {
 			actions = append(actions, line)
 		} else if currentSection == "timeline" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "•") || regexp.MustCompile(`^\d+\.`).MatchString(line)) {
-			timeline = append(timeline, line)
+			timeline = append(timeline, ParseTimelineStep(len(timeline)+1, line))
 		}
 	}
 
-	return rootCause, riskLevel, confidence, actions, timeline
+	var warnings []string
+	warnings = missing(warnings, rootCause == "", "ROOT CAUSE")
+	if !riskFound {
+		warnings = append(warnings, "RISK LEVEL missing; assumed MEDIUM")
+	}
+	if !confidenceFound {
+		warnings = append(warnings, "CONFIDENCE missing or not a number; assumed 0.75")
+	}
+	warnings = missing(warnings, len(actions) == 0, "IMMEDIATE ACTIONS")
+	warnings = missing(warnings, len(timeline) == 0, "RECOVERY TIMELINE")
+
+	return rootCause, riskLevel, confidence, actions, timeline, warnings
 }
 
-func ParseSafetyResponse(text string) (string, []map[string]string, []string, []string) {
+// HazardRating is a hazard as rated by the model, before the ratings are
+// placed on a risk matrix. A rating of 0 means the model did not provide it.
+type HazardRating struct {
+	Name               string
+	Severity           int
+	Likelihood         int
+	ResidualSeverity   int
+	ResidualLikelihood int
+}
+
+func ParseSafetyResponse(text string) (string, []HazardRating, []string, []string, []string) {
 	hazardLevel := "MEDIUM"
-	var hazards []map[string]string
+	levelFound := false
+	var hazards []HazardRating
 	var mitigations []string
 	var standards []string
 
@@ -161,6 +206,7 @@ func ParseSafetyResponse(text string) (string, []map[string]string, []string, []
 			parts := strings.SplitN(line, ":", 2)
 			if len(parts) > 1 {
 				level := strings.ToUpper(strings.TrimSpace(parts[1]))
+				levelFound = level != ""
 				if strings.Contains(level, "HIGH") {
 					hazardLevel = "HIGH"
 				} else if strings.Contains(level, "LOW") {
@@ -182,17 +228,7 @@ func ParseSafetyResponse(text string) (string, []map[string]string, []string, []
 		}
 
 		if currentSection == "hazards" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "•") || regexp.MustCompile(`^\d+\.`).MatchString(line)) {
-			hazard := map[string]string{
-				"name":        line,
-				"severity":    "Medium",
-				"probability": "Medium",
-			}
-			if strings.Contains(strings.ToLower(line), "high") || strings.Contains(strings.ToLower(line), "critical") {
-				hazard["severity"] = "High"
-			} else if strings.Contains(strings.ToLower(line), "low") || strings.Contains(strings.ToLower(line), "minor") {
-				hazard["severity"] = "Low"
-			}
-			hazards = append(hazards, hazard)
+			hazards = append(hazards, parseHazardLine(line))
 		} else if currentSection == "mitigations" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "•") || regexp.MustCompile(`^\d+\.`).MatchString(line)) {
 			mitigations = append(mitigations, line)
 		} else if currentSection == "standards" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "•") || regexp.MustCompile(`^\d+\.`).MatchString(line)) {
@@ -200,18 +236,98 @@ func ParseSafetyResponse(text string) (string, []map[string]string, []string, []
 		}
 	}
 
-	return hazardLevel, hazards, mitigations, standards
+	var warnings []string
+	if !levelFound {
+		warnings = append(warnings, "HAZARD LEVEL missing; assumed MEDIUM")
+	}
+	warnings = missing(warnings, len(hazards) == 0, "IDENTIFIED HAZARDS")
+	for _, h := range hazards {
+		if h.Severity == 0 || h.Likelihood == 0 {
+			warnings = append(warnings, fmt.Sprintf("hazard %q is missing its SEVERITY or LIKELIHOOD rating", h.Name))
+		}
+	}
+	warnings = missing(warnings, len(mitigations) == 0, "RECOMMENDED MITIGATIONS")
+
+	return hazardLevel, hazards, mitigations, standards, warnings
 }
 
-func ParseCorrosionResponse(text string) (string, float64, []string, []string, string) {
+// parseHazardLine reads a line of the form
+// "- Name | SEVERITY: 4 | LIKELIHOOD: 3 | RESIDUAL SEVERITY: 4 | RESIDUAL LIKELIHOOD: 1".
+// Segments without a recognised key are ignored.
+func parseHazardLine(line string) HazardRating {
+	// Citation labels such as [D1] would otherwise be read as ratings.
+	line = StripCitations(line)
+	segments := strings.Split(line, "|")
+	hazard := HazardRating{Name: strings.TrimSpace(segments[0])}
+
+	for _, segment := range segments[1:] {
+		parts := strings.SplitN(segment, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		match := regexp.MustCompile(`\d+`).FindString(parts[1])
+		if match == "" {
+			continue
+		}
+		rating, err := strconv.Atoi(match)
+		if err != nil {
+			continue
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(parts[0])) {
+		case "SEVERITY":
+			hazard.Severity = rating
+		case "LIKELIHOOD", "PROBABILITY":
+			hazard.Likelihood = rating
+		case "RESIDUAL SEVERITY":
+			hazard.ResidualSeverity = rating
+		case "RESIDUAL LIKELIHOOD", "RESIDUAL PROBABILITY":
+			hazard.ResidualLikelihood = rating
+		}
+	}
+
+	return hazard
+}
+
+func ParseCorrosionResponse(text string) (string, float64, []string, []string, string, []string) {
 	riskLevel := "MEDIUM"
 	corrosionRate := 0.5
 	var mechanisms []string
 	var recommendations []string
 	estimatedLife := "10-15 years"
+	var riskFound, rateFound, lifeFound bool
+
+	// The prompt puts each value on the line after its heading, but models
+	// often answer on the same line, so a heading with nothing after the
+	// colon takes the next line as its value.
+	setRisk := func(value string) {
+		level := strings.ToUpper(value)
+		riskFound = true
+		if strings.Contains(level, "HIGH") {
+			riskLevel = "HIGH"
+		} else if strings.Contains(level, "LOW") {
+			riskLevel = "LOW"
+		} else {
+			riskLevel = "MEDIUM"
+		}
+	}
+	setRate := func(value string) {
+		re := regexp.MustCompile(`[\d.]+`)
+		if match := re.FindString(value); match != "" {
+			if val, err := strconv.ParseFloat(match, 64); err == nil {
+				corrosionRate = val
+				rateFound = true
+			}
+		}
+	}
+	setLife := func(value string) {
+		estimatedLife = value
+		lifeFound = true
+	}
 
 	lines := strings.Split(text, "\n")
 	var currentSection string
+	var pending func(string)
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -221,45 +337,40 @@ func ParseCorrosionResponse(text string) (string, float64, []string, []string, s
 
 		upperLine := strings.ToUpper(line)
 
-		if strings.Contains(upperLine, "RISK LEVEL:") || strings.Contains(upperLine, "CORROSION RISK:") {
-			currentSection = ""
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) > 1 {
-				level := strings.ToUpper(strings.TrimSpace(parts[1]))
-				if strings.Contains(level, "HIGH") {
-					riskLevel = "HIGH"
-				} else if strings.Contains(level, "LOW") {
-					riskLevel = "LOW"
-				} else {
-					riskLevel = "MEDIUM"
-				}
-			}
-			continue
-		} else if strings.Contains(upperLine, "CORROSION RATE:") {
-			currentSection = ""
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) > 1 {
-				rateStr := strings.TrimSpace(parts[1])
-				re := regexp.MustCompile(`[\d.]+`)
-				if match := re.FindString(rateStr); match != "" {
-					if val, err := strconv.ParseFloat(match, 64); err == nil {
-						corrosionRate = val
-					}
-				}
-			}
-			continue
-		} else if strings.Contains(upperLine, "ESTIMATED LIFE:") || strings.Contains(upperLine, "EQUIPMENT LIFE:") {
+		var set func(string)
+		switch {
+		case strings.Contains(upperLine, "RISK LEVEL:") || strings.Contains(upperLine, "CORROSION RISK:"):
+			set = setRisk
+		case strings.Contains(upperLine, "CORROSION RATE:"):
+			set = setRate
+		case strings.Contains(upperLine, "ESTIMATED LIFE:") || strings.Contains(upperLine, "EQUIPMENT LIFE:"):
+			set = setLife
+		}
+		if set != nil {
 			currentSection = ""
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) > 1 {
-				estimatedLife = strings.TrimSpace(parts[1])
+			pending = nil
+			value := strings.TrimSpace(strings.SplitN(line, ":", 2)[1])
+			if value != "" {
+				set(value)
+			} else {
+				pending = set
 			}
 			continue
-		} else if strings.Contains(upperLine, "MECHANISMS:") || strings.Contains(upperLine, "CORROSION MECHANISMS:") {
+		}
+
+		if strings.Contains(upperLine, "MECHANISMS:") || strings.Contains(upperLine, "CORROSION MECHANISMS:") {
 			currentSection = "mechanisms"
+			pending = nil
 			continue
 		} else if strings.Contains(upperLine, "RECOMMENDATIONS:") {
 			currentSection = "recommendations"
+			pending = nil
+			continue
+		}
+
+		if pending != nil {
+			pending(line)
+			pending = nil
 			continue
 		}
 
@@ -270,5 +381,18 @@ func ParseCorrosionResponse(text string) (string, float64, []string, []string, s
 		}
 	}
 
-	return riskLevel, corrosionRate, mechanisms, recommendations, estimatedLife
+	var warnings []string
+	if !riskFound {
+		warnings = append(warnings, "CORROSION RISK missing; assumed MEDIUM")
+	}
+	if !rateFound {
+		warnings = append(warnings, "CORROSION RATE missing or not a number; assumed 0.5 mm/y")
+	}
+	warnings = missing(warnings, len(mechanisms) == 0, "CORROSION MECHANISMS")
+	warnings = missing(warnings, len(recommendations) == 0, "RECOMMENDATIONS")
+	if !lifeFound {
+		warnings = append(warnings, "ESTIMATED LIFE missing; assumed 10-15 years")
+	}
+
+	return riskLevel, corrosionRate, mechanisms, recommendations, estimatedLife, warnings
 }
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// RiskMatrix describes a company risk matrix. Severity and likelihood are
+// rated from 1 to len(labels); the risk score is read from Scores when
+// provided, otherwise it is severity multiplied by likelihood.
+type RiskMatrix struct {
+	Name             string     `json:"name"`
+	SeverityLabels   []string   `json:"severityLabels"`
+	LikelihoodLabels []string   `json:"likelihoodLabels"`
+	Scores           [][]int    `json:"scores,omitempty"`
+	Bands            []RiskBand `json:"bands"`
+}
+
+// RiskBand maps every score at or above MinScore to Level. Bands are
+// evaluated from the highest MinScore down.
+type RiskBand struct {
+	Level    string `json:"level"`
+	MinScore int    `json:"minScore"`
+}
+
+func DefaultRiskMatrix() *RiskMatrix {
+	return &RiskMatrix{
+		Name:             "Default 5x5",
+		SeverityLabels:   []string{"Negligible", "Minor", "Moderate", "Major", "Catastrophic"},
+		LikelihoodLabels: []string{"Rare", "Unlikely", "Possible", "Likely", "Almost Certain"},
+		Bands: []RiskBand{
+			{Level: "LOW", MinScore: 1},
+			{Level: "MEDIUM", MinScore: 5},
+			{Level: "HIGH", MinScore: 10},
+		},
+	}
+}
+
+// LoadRiskMatrix reads the matrix at path, falling back to the default 5x5
+// matrix when path is empty.
+func LoadRiskMatrix(path string) (*RiskMatrix, error) {
+	if path == "" {
+		return DefaultRiskMatrix(), nil
+	}
+	return LoadRiskMatrixFile(path)
+}
+
+func LoadRiskMatrixFile(path string) (*RiskMatrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading risk matrix: %w", err)
+	}
+
+	var m RiskMatrix
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing risk matrix: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *RiskMatrix) Validate() error {
+	if len(m.SeverityLabels) == 0 || len(m.LikelihoodLabels) == 0 {
+		return fmt.Errorf("risk matrix %q needs severity and likelihood labels", m.Name)
+	}
+	if len(m.Bands) == 0 {
+		return fmt.Errorf("risk matrix %q needs at least one risk band", m.Name)
+	}
+	if m.Scores != nil {
+		if len(m.Scores) != len(m.SeverityLabels) {
+			return fmt.Errorf("risk matrix %q has %d score rows, want %d", m.Name, len(m.Scores), len(m.SeverityLabels))
+		}
+		for i, row := range m.Scores {
+			if len(row) != len(m.LikelihoodLabels) {
+				return fmt.Errorf("risk matrix %q score row %d has %d columns, want %d", m.Name, i+1, len(row), len(m.LikelihoodLabels))
+			}
+		}
+	}
+	return nil
+}
+
+func (m *RiskMatrix) SeverityLevels() int {
+	return len(m.SeverityLabels)
+}
+
+func (m *RiskMatrix) LikelihoodLevels() int {
+	return len(m.LikelihoodLabels)
+}
+
+// Score returns the risk score for a severity/likelihood pair, or 0 when
+// either rating is outside the matrix.
+func (m *RiskMatrix) Score(severity, likelihood int) int {
+	if severity < 1 || severity > m.SeverityLevels() || likelihood < 1 || likelihood > m.LikelihoodLevels() {
+		return 0
+	}
+	if m.Scores != nil {
+		return m.Scores[severity-1][likelihood-1]
+	}
+	return severity * likelihood
+}
+
+// Level returns the band for a score. A score of 0 means the hazard could
+// not be rated and is reported as UNRATED.
+func (m *RiskMatrix) Level(score int) string {
+	if score <= 0 {
+		return "UNRATED"
+	}
+	level := ""
+	best := -1
+	for _, b := range m.Bands {
+		if score >= b.MinScore && b.MinScore > best {
+			level = b.Level
+			best = b.MinScore
+		}
+	}
+	if level == "" {
+		return "UNRATED"
+	}
+	return strings.ToUpper(level)
+}
+
+// Rank orders levels by their position in the matrix bands so overall
+// levels can be compared. Unknown levels rank lowest.
+func (m *RiskMatrix) Rank(level string) int {
+	rank := 0
+	for _, b := range m.Bands {
+		if strings.EqualFold(b.Level, level) && b.MinScore > rank {
+			rank = b.MinScore
+		}
+	}
+	return rank
+}
+
+func (m *RiskMatrix) SeverityLabel(rating int) string {
+	if rating < 1 || rating > m.SeverityLevels() {
+		return "Unrated"
+	}
+	return m.SeverityLabels[rating-1]
+}
+
+func (m *RiskMatrix) LikelihoodLabel(rating int) string {
+	if rating < 1 || rating > m.LikelihoodLevels() {
+		return "Unrated"
+	}
+	return m.LikelihoodLabels[rating-1]
+}
+
+// PromptScale renders the rating scales for inclusion in a model prompt.
+func (m *RiskMatrix) PromptScale() string {
+	var b strings.Builder
+	b.WriteString("SEVERITY SCALE:\n")
+	for i, label := range m.SeverityLabels {
+		fmt.Fprintf(&b, "%d = %s\n", i+1, label)
+	}
+	b.WriteString("\nLIKELIHOOD SCALE:\n")
+	for i, label := range m.LikelihoodLabels {
+		fmt.Fprintf(&b, "%d = %s\n", i+1, label)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"pcst-ai/backend/models"
+)
+
+var (
+	bulletPrefix   = regexp.MustCompile(`^(?:[-•*]|\d+[.)])\s*`)
+	timeRange      = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*([a-z]+)?\s*(?:-|–|—|to)\s*(\d+(?:\.\d+)?)\s*([a-z]+)`)
+	timeWithin     = regexp.MustCompile(`(?i)\b(?:within|next|first|up to)\s+(?:the\s+)?(\d+(?:\.\d+)?)\s*([a-z]+)`)
+	timeAfter      = regexp.MustCompile(`(?i)(?:\b(?:after|beyond|from)\s+(\d+(?:\.\d+)?)\s*([a-z]+)|(\d+(?:\.\d+)?)\s*\+\s*([a-z]+)|(\d+(?:\.\d+)?)\s*([a-z]+)\s*\+)`)
+	timePoint      = regexp.MustCompile(`(?i)(?:t\s*\+\s*)?(\d+(?:\.\d+)?)\s*([a-z]+)`)
+	timeImmediate  = regexp.MustCompile(`(?i)\b(?:immediate(?:ly)?|now|t\s*\+?\s*0)\b`)
+	timeOngoing    = regexp.MustCompile(`(?i)\b(?:ongoing|continuous(?:ly)?|long[- ]term)\b`)
+	minutesPerUnit = map[string]float64{
+		"s": 1.0 / 60, "sec": 1.0 / 60, "secs": 1.0 / 60, "second": 1.0 / 60, "seconds": 1.0 / 60,
+		"m": 1, "min": 1, "mins": 1, "minute": 1, "minutes": 1,
+		"h": 60, "hr": 60, "hrs": 60, "hour": 60, "hours": 60,
+		"shift": 720, "shifts": 720,
+		"d": 1440, "day": 1440, "days": 1440,
+		"w": 10080, "wk": 10080, "wks": 10080, "week": 10080, "weeks": 10080,
+	}
+)
+
+// ParseTimelineStep turns one RECOVERY TIMELINE line into a structured step.
+// The expected form is "- Phase | Window | Role | Action"; lines that do not
+// follow it keep their text as the action and still get a window when one
+// can be recognised.
+func ParseTimelineStep(sequence int, line string) models.TimelineStep {
+	step := models.TimelineStep{
+		Sequence: sequence,
+		Text:     line,
+	}
+
+	body := bulletPrefix.ReplaceAllString(strings.TrimSpace(line), "")
+	fields := strings.Split(body, "|")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	switch {
+	case len(fields) >= 4:
+		step.Phase = fields[0]
+		step.Window = fields[1]
+		step.Role = fields[2]
+		step.Action = strings.Join(fields[3:], " | ")
+	case len(fields) == 3:
+		step.Window = fields[0]
+		step.Role = fields[1]
+		step.Action = fields[2]
+	case len(fields) == 2:
+		step.Window = fields[0]
+		step.Action = fields[1]
+	default:
+		step.Action = body
+		if parts := strings.SplitN(body, ":", 2); len(parts) == 2 {
+			if _, _, _, ok := ParseTimeWindow(parts[0]); ok {
+				step.Window = strings.TrimSpace(parts[0])
+				step.Action = strings.TrimSpace(parts[1])
+			}
+		}
+	}
+
+	window := step.Window
+	if window == "" {
+		window = body
+	}
+	start, end, openEnded, ok := ParseTimeWindow(window)
+	if ok {
+		step.Scheduled = true
+		step.StartMinutes = start
+		step.EndMinutes = end
+		step.OpenEnded = openEnded
+	}
+
+	return step
+}
+
+// ParseTimeWindow normalises phrases such as "0-15 min", "within 2 hours",
+// "after 4 hours" or "T+30 min" into minute offsets from the incident. For
+// open-ended windows end equals start.
+func ParseTimeWindow(text string) (start, end float64, openEnded, ok bool) {
+	text = strings.TrimSpace(text)
+
+	if m := timeRange.FindStringSubmatch(text); m != nil {
+		endUnit, known := unitMinutes(m[4])
+		if known {
+			startUnit := endUnit
+			if m[2] != "" {
+				if u, ok := unitMinutes(m[2]); ok {
+					startUnit = u
+				}
+			}
+			from, _ := strconv.ParseFloat(m[1], 64)
+			to, _ := strconv.ParseFloat(m[3], 64)
+			return from * startUnit, to * endUnit, false, true
+		}
+	}
+
+	if m := timeWithin.FindStringSubmatch(text); m != nil {
+		if unit, known := unitMinutes(m[2]); known {
+			value, _ := strconv.ParseFloat(m[1], 64)
+			return 0, value * unit, false, true
+		}
+	}
+
+	if m := timeAfter.FindStringSubmatch(text); m != nil {
+		for i := 1; i < len(m); i += 2 {
+			if m[i] == "" {
+				continue
+			}
+			if unit, known := unitMinutes(m[i+1]); known {
+				value, _ := strconv.ParseFloat(m[i], 64)
+				return value * unit, value * unit, true, true
+			}
+		}
+	}
+
+	if timeImmediate.MatchString(text) {
+		return 0, 0, false, true
+	}
+
+	if timeOngoing.MatchString(text) {
+		return 0, 0, true, true
+	}
+
+	for _, m := range timePoint.FindAllStringSubmatch(text, -1) {
+		if unit, known := unitMinutes(m[2]); known {
+			value, _ := strconv.ParseFloat(m[1], 64)
+			return value * unit, value * unit, false, true
+		}
+	}
+
+	return 0, 0, false, false
+}
+
+func unitMinutes(unit string) (float64, bool) {
+	minutes, ok := minutesPerUnit[strings.ToLower(strings.TrimSuffix(unit, "."))]
+	return minutes, ok
+}
@@ -0,0 +1,145 @@
+// Package sites loads the plants the service runs for and the data each one
+// keeps apart: its equipment catalog, risk matrix, loop diagrams and
+// documents.
+package sites
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"pcst-ai/backend/catalog"
+	"pcst-ai/backend/docindex"
+	"pcst-ai/backend/ild"
+	"pcst-ai/backend/models"
+	"pcst-ai/backend/services"
+)
+
+// DefaultID names the only site when no sites file is configured.
+const DefaultID = "default"
+
+// Site is a configured plant. Catalog, RiskMatrix, Loops and Documents are
+// nil when the site shares the global ones.
+type Site struct {
+	models.Site
+	EquipmentCatalogFile string `json:"equipmentCatalogFile"`
+	RiskMatrixFile       string `json:"riskMatrixFile"`
+	LoopDiagramsFile     string `json:"loopDiagramsFile"`
+	DocumentIndexFile    string `json:"documentIndexFile"`
+
+	Catalog    catalog.Store        `json:"-"`
+	RiskMatrix *services.RiskMatrix `json:"-"`
+	Loops      ild.Store            `json:"-"`
+	Documents  *docindex.Index      `json:"-"`
+}
+
+// Default is the site used when no sites file is configured. It keeps the
+// wording the prompts had before sites existed.
+func Default() *Site {
+	return &Site{Site: models.Site{
+		ID:           DefaultID,
+		Name:         "Default",
+		Organization: "Aramco",
+		SafetyRules:  []string{"Follow Aramco safety protocols"},
+		Standards:    []string{"Aramco safety standards"},
+	}}
+}
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Registry holds the configured sites. The first one is the default for
+// callers who neither name a site nor are limited to any.
+type Registry struct {
+	sites []*Site
+	byID  map[string]*Site
+}
+
+// New checks sites and opens their own data files.
+func New(list []*Site) (*Registry, error) {
+	if len(list) == 0 {
+		return nil, fmt.Errorf("at least one site is required")
+	}
+	r := &Registry{byID: map[string]*Site{}}
+	for i, s := range list {
+		if !validID.MatchString(s.ID) {
+			return nil, fmt.Errorf("site %d: id %q must be lowercase letters, digits and dashes", i+1, s.ID)
+		}
+		if _, dup := r.byID[s.ID]; dup {
+			return nil, fmt.Errorf("site %q is listed twice", s.ID)
+		}
+		if s.Name == "" {
+			s.Name = s.ID
+		}
+		if s.SafetyRules == nil {
+			s.SafetyRules = []string{}
+		}
+		if s.Standards == nil {
+			s.Standards = []string{}
+		}
+
+		if s.EquipmentCatalogFile != "" {
+			store, err := catalog.OpenFile(s.EquipmentCatalogFile)
+			if err != nil {
+				return nil, fmt.Errorf("site %q: %w", s.ID, err)
+			}
+			s.Catalog = store
+		}
+		if s.RiskMatrixFile != "" {
+			m, err := services.LoadRiskMatrixFile(s.RiskMatrixFile)
+			if err != nil {
+				return nil, fmt.Errorf("site %q: %w", s.ID, err)
+			}
+			s.RiskMatrix = m
+		}
+		if s.LoopDiagramsFile != "" {
+			store, err := ild.OpenFile(s.LoopDiagramsFile)
+			if err != nil {
+				return nil, fmt.Errorf("site %q: %w", s.ID, err)
+			}
+			s.Loops = store
+		}
+		if s.DocumentIndexFile != "" {
+			ix, err := docindex.Open(s.DocumentIndexFile)
+			if err != nil {
+				return nil, fmt.Errorf("site %q: %w", s.ID, err)
+			}
+			s.Documents = ix
+		}
+
+		r.sites = append(r.sites, s)
+		r.byID[s.ID] = s
+	}
+	return r, nil
+}
+
+// Load reads a JSON array of sites from path. An empty path configures only
+// the Default site.
+func Load(path string) (*Registry, error) {
+	if path == "" {
+		return New([]*Site{Default()})
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading sites: %w", err)
+	}
+	var list []*Site
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parsing sites: %w", err)
+	}
+	return New(list)
+}
+
+func (r *Registry) Get(id string) (*Site, bool) {
+	s, ok := r.byID[id]
+	return s, ok
+}
+
+func (r *Registry) Default() *Site {
+	return r.sites[0]
+}
+
+// List returns the sites in configured order.
+func (r *Registry) List() []*Site {
+	return r.sites
+}
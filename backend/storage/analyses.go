@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+var (
+	ErrNotFound      = errors.New("analysis not found")
+	ErrInvalidCursor = errors.New("cursor does not belong to this query")
+)
+
+// SortFields maps the sort keys accepted by ListAnalyses to their columns.
+var SortFields = map[string]string{
+	"startedAt":   "started_at",
+	"completedAt": "completed_at",
+	"durationMs":  "duration_ms",
+	"type":        "type",
+	"equipment":   "equipment",
+	"riskLevel":   "risk_level",
+	"user":        "user_id",
+}
+
+// HistoryQuery filters and pages analysis history. Empty fields match
+// everything. From is inclusive and To exclusive, both on StartedAt. Text is
+// a full-text search over the equipment, request and parsed result. Sort is
+// a key of SortFields, defaulting to startedAt; ties are broken by ID. The
+// results are limited to Sites unless AllSites is set.
+type HistoryQuery struct {
+	Type      string
+	Equipment string
+	RiskLevel string
+	User      string
+	Site      string
+	Sites     []string
+	AllSites  bool
+	From      time.Time
+	To        time.Time
+	Text      string
+	Sort      string
+	Ascending bool
+	Cursor    string
+	Limit     int
+}
+
+// cursor records where a page ended, and the order it was read in so it
+// cannot be replayed against a different sort.
+type cursor struct {
+	Sort      string          `json:"s"`
+	Ascending bool            `json:"a,omitempty"`
+	Value     json.RawMessage `json:"v"`
+	ID        string          `json:"id"`
+}
+
+const summaryColumns = `id, type, request_id, user_id, site, equipment, risk_level, status, error, model, prompt_version,
+    started_at, completed_at, duration_ms`
+
+func (d *DB) SaveAnalysis(ctx context.Context, rec models.AnalysisRecord) error {
+	var result interface{}
+	if rec.Result != nil {
+		result = string(rec.Result)
+	}
+
+	_, err := d.db.ExecContext(ctx, d.rebind(`INSERT INTO analyses (
+    id, type, request_id, user_id, site, equipment, risk_level, status, error, model, prompt_version,
+    request, prompt, raw_output, result, started_at, completed_at, duration_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		rec.ID, rec.Type, rec.RequestID, rec.User, rec.Site, rec.Equipment, rec.RiskLevel, rec.Status, rec.Error, rec.Model, rec.PromptVersion,
+		string(rec.Request), rec.Prompt, rec.RawOutput, result, rec.StartedAt, rec.CompletedAt, rec.DurationMs)
+	return err
+}
+
+func (d *DB) GetAnalysis(ctx context.Context, id string) (models.AnalysisRecord, error) {
+	var (
+		rec            models.AnalysisRecord
+		request        string
+		result         sql.NullString
+		summaryTargets = summaryFields(&rec.AnalysisSummary)
+	)
+	row := d.db.QueryRowContext(ctx, d.rebind(`SELECT `+summaryColumns+`, request, prompt, raw_output, result
+FROM analyses WHERE id = ?`), id)
+	err := row.Scan(append(summaryTargets, &request, &rec.Prompt, &rec.RawOutput, &result)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AnalysisRecord{}, ErrNotFound
+	}
+	if err != nil {
+		return models.AnalysisRecord{}, err
+	}
+
+	rec.Request = json.RawMessage(request)
+	if result.Valid {
+		rec.Result = json.RawMessage(result.String)
+	}
+	normalizeTimes(&rec.AnalysisSummary)
+	rec.Approval, err = d.approval(ctx, id)
+	return rec, err
+}
+
+func (d *DB) ListAnalyses(ctx context.Context, q HistoryQuery) (models.HistoryPage, error) {
+	if q.Sort == "" {
+		q.Sort = "startedAt"
+	}
+	column, ok := SortFields[q.Sort]
+	if !ok {
+		return models.HistoryPage{}, fmt.Errorf("unknown sort field %q", q.Sort)
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	filter := func(clause string, values ...interface{}) {
+		where = append(where, clause)
+		args = append(args, values...)
+	}
+	if q.Type != "" {
+		filter("type = ?", q.Type)
+	}
+	if q.Equipment != "" {
+		filter("LOWER(equipment) = LOWER(?)", q.Equipment)
+	}
+	if q.RiskLevel != "" {
+		filter("LOWER(risk_level) = LOWER(?)", q.RiskLevel)
+	}
+	if q.User != "" {
+		filter("user_id = ?", q.User)
+	}
+	if q.Site != "" {
+		filter("site = ?", q.Site)
+	}
+	if !q.AllSites {
+		clause, sites := siteFilter("site", q.Sites)
+		filter(clause, sites...)
+	}
+	if !q.From.IsZero() {
+		filter("started_at >= ?", q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		filter("started_at < ?", q.To.UTC())
+	}
+	if text := strings.TrimSpace(q.Text); text != "" {
+		if d.dialect == DialectPostgres {
+			filter("search @@ plainto_tsquery('english', ?)", text)
+		} else if match := ftsQuery(text); match != "" {
+			filter("rowid IN (SELECT docid FROM analyses_fts WHERE analyses_fts MATCH ?)", match)
+		}
+	}
+
+	if q.Cursor != "" {
+		after, afterID, err := decodeCursor(q.Cursor, q.Sort, q.Ascending)
+		if err != nil {
+			return models.HistoryPage{}, err
+		}
+		op := "<"
+		if q.Ascending {
+			op = ">"
+		}
+		filter(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op), after, after, afterID)
+	}
+
+	order := "DESC"
+	if q.Ascending {
+		order = "ASC"
+	}
+	query := `SELECT ` + summaryColumns + ` FROM analyses`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT ?", column, order, order)
+	// One extra row tells whether there is another page.
+	args = append(args, q.Limit+1)
+
+	rows, err := d.db.QueryContext(ctx, d.rebind(query), args...)
+	if err != nil {
+		return models.HistoryPage{}, err
+	}
+	defer rows.Close()
+
+	page := models.HistoryPage{Items: []models.AnalysisSummary{}}
+	for rows.Next() {
+		var s models.AnalysisSummary
+		if err := rows.Scan(summaryFields(&s)...); err != nil {
+			return models.HistoryPage{}, err
+		}
+		normalizeTimes(&s)
+		page.Items = append(page.Items, s)
+	}
+	if err := rows.Err(); err != nil {
+		return models.HistoryPage{}, err
+	}
+
+	if len(page.Items) > q.Limit {
+		page.Items = page.Items[:q.Limit]
+		last := page.Items[len(page.Items)-1]
+		page.NextCursor, err = encodeCursor(q.Sort, q.Ascending, sortValue(last, q.Sort), last.ID)
+		if err != nil {
+			return models.HistoryPage{}, err
+		}
+	}
+	return page, nil
+}
+
+func summaryFields(s *models.AnalysisSummary) []interface{} {
+	return []interface{}{
+		&s.ID, &s.Type, &s.RequestID, &s.User, &s.Site, &s.Equipment, &s.RiskLevel, &s.Status, &s.Error, &s.Model, &s.PromptVersion,
+		&s.StartedAt, &s.CompletedAt, &s.DurationMs,
+	}
+}
+
+// normalizeTimes reports stored times in UTC whatever zone the driver used.
+func normalizeTimes(s *models.AnalysisSummary) {
+	s.StartedAt = s.StartedAt.UTC()
+	s.CompletedAt = s.CompletedAt.UTC()
+}
+
+func sortValue(s models.AnalysisSummary, sort string) interface{} {
+	switch sort {
+	case "completedAt":
+		return s.CompletedAt
+	case "durationMs":
+		return s.DurationMs
+	case "type":
+		return s.Type
+	case "equipment":
+		return s.Equipment
+	case "riskLevel":
+		return s.RiskLevel
+	case "user":
+		return s.User
+	}
+	return s.StartedAt
+}
+
+func encodeCursor(sort string, ascending bool, value interface{}, id string) (string, error) {
+	v, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(cursor{Sort: sort, Ascending: ascending, Value: v, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the sort value and ID a page ended on, the value
+// typed to match its column.
+func decodeCursor(raw, sort string, ascending bool) (interface{}, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort || c.Ascending != ascending {
+		return nil, "", ErrInvalidCursor
+	}
+
+	switch sort {
+	case "startedAt", "completedAt":
+		var t time.Time
+		if err := json.Unmarshal(c.Value, &t); err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		return t.UTC(), c.ID, nil
+	case "durationMs":
+		var n int64
+		if err := json.Unmarshal(c.Value, &n); err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		return n, c.ID, nil
+	default:
+		var s string
+		if err := json.Unmarshal(c.Value, &s); err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		return s, c.ID, nil
+	}
+}
+
+// ftsQuery turns free text into an FTS4 query matching every word, so
+// operators typed by the user are searched for rather than interpreted.
+func ftsQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word != "" {
+			terms = append(terms, `"`+word+`"`)
+		}
+	}
+	return strings.Join(terms, " ")
+}
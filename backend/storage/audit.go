@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pcst-ai/backend/audit"
+	"pcst-ai/backend/models"
+)
+
+// AuditQuery selects entries by when they were appended. From is inclusive
+// and To exclusive; zero values are unbounded. Only entries of Sites are
+// kept unless AllSites is set.
+type AuditQuery struct {
+	From     time.Time
+	To       time.Time
+	Sites    []string
+	AllSites bool
+}
+
+// AppendAudit links e onto the end of the chain, filling in its sequence
+// number and hashes, and returns it as stored.
+func (d *DB) AppendAudit(ctx context.Context, e models.AuditEntry, key []byte) (models.AuditEntry, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e, err
+	}
+	defer tx.Rollback()
+
+	if d.dialect == DialectPostgres {
+		// Writers from other instances must not chain onto the same entry.
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE audit_log IN EXCLUSIVE MODE`); err != nil {
+			return e, err
+		}
+	}
+
+	e.Seq, e.PrevHash = 1, audit.Genesis
+	var last int64
+	var prev string
+	err = tx.QueryRowContext(ctx, `SELECT seq, hash FROM audit_log ORDER BY seq DESC LIMIT 1`).Scan(&last, &prev)
+	switch {
+	case err == nil:
+		e.Seq, e.PrevHash = last+1, prev
+	case !errors.Is(err, sql.ErrNoRows):
+		return e, err
+	}
+
+	e.CreatedAt = e.CreatedAt.UTC()
+	if e.Hash, err = audit.Hash(key, e); err != nil {
+		return e, err
+	}
+	payload, err := audit.Payload(e)
+	if err != nil {
+		return e, err
+	}
+
+	_, err = tx.ExecContext(ctx, d.rebind(`INSERT INTO audit_log (seq, kind, analysis_id, user_id, created_at, entry, prev_hash, hash)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`), e.Seq, e.Kind, e.AnalysisID, e.User, e.CreatedAt, string(payload), e.PrevHash, e.Hash)
+	if err != nil {
+		return e, err
+	}
+	return e, tx.Commit()
+}
+
+// auditPageSize is how many entries ScanAudit reads per query.
+const auditPageSize = 500
+
+// ScanAudit calls fn with each entry in sequence order. Entries are decoded
+// from the stored payload, which is what the hash covers; fn stops the scan
+// by returning an error. Entries are read a page at a time and fn only runs
+// between queries, so a slow consumer such as a download does not hold a
+// connection that analyses need to record their results.
+func (d *DB) ScanAudit(ctx context.Context, q AuditQuery, fn func(models.AuditEntry) error) error {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if !q.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, q.To.UTC())
+	}
+	if !q.AllSites {
+		// The site is only kept in the hashed payload.
+		site := `json_extract(entry, '$.site')`
+		if d.dialect == DialectPostgres {
+			site = `(entry::jsonb ->> 'site')`
+		}
+		clause, sites := siteFilter(site, q.Sites)
+		where = append(where, clause)
+		args = append(args, sites...)
+	}
+	where = append(where, "seq > ?")
+	query := d.rebind(`SELECT seq, entry, hash FROM audit_log WHERE ` + strings.Join(where, " AND ") +
+		fmt.Sprintf(" ORDER BY seq LIMIT %d", auditPageSize))
+
+	var after int64
+	for {
+		page, last, err := d.auditPage(ctx, query, append(args[:len(args):len(args)], after))
+		if err != nil {
+			return err
+		}
+		for _, e := range page {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if len(page) < auditPageSize {
+			return nil
+		}
+		after = last
+	}
+}
+
+// auditPage runs one page query of ScanAudit and closes its rows. It also
+// returns the sequence number of the last row read.
+func (d *DB) auditPage(ctx context.Context, query string, args []interface{}) ([]models.AuditEntry, int64, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		page []models.AuditEntry
+		last int64
+	)
+	for rows.Next() {
+		var (
+			seq     int64
+			payload string
+			hash    string
+			e       models.AuditEntry
+		)
+		if err := rows.Scan(&seq, &payload, &hash); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal([]byte(payload), &e); err != nil {
+			// An unreadable payload is reported as tampering, not a failure.
+			e = models.AuditEntry{Seq: seq}
+		}
+		e.Hash = hash
+		page = append(page, e)
+		last = seq
+	}
+	return page, last, rows.Err()
+}
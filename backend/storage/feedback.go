@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+// OutcomeQuery selects the feedback an accuracy report covers. Empty
+// fields match everything; From is inclusive and To exclusive, both on when
+// the feedback was given. It is limited to analyses of Sites unless AllSites
+// is set.
+type OutcomeQuery struct {
+	Type      string
+	Equipment string
+	Site      string
+	Sites     []string
+	AllSites  bool
+	From      time.Time
+	To        time.Time
+}
+
+func (d *DB) SaveFeedback(ctx context.Context, f models.Feedback) error {
+	correct, err := json.Marshal(f.Correct)
+	if err != nil {
+		return err
+	}
+	incorrect, err := json.Marshal(f.Incorrect)
+	if err != nil {
+		return err
+	}
+	var timeToFix interface{}
+	if f.TimeToFixMinutes != nil {
+		timeToFix = *f.TimeToFixMinutes
+	}
+
+	_, err = d.db.ExecContext(ctx, d.rebind(`INSERT INTO feedback (
+    id, analysis_id, user_id, site, rating, correct, incorrect, correct_count, incorrect_count,
+    resolution, time_to_fix_minutes, comment, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		f.ID, f.AnalysisID, f.User, f.Site, f.Rating, string(correct), string(incorrect), len(f.Correct), len(f.Incorrect),
+		f.Resolution, timeToFix, f.Comment, f.CreatedAt)
+	return err
+}
+
+// ListFeedback returns the feedback on one analysis, oldest first.
+func (d *DB) ListFeedback(ctx context.Context, analysisID string) ([]models.Feedback, error) {
+	rows, err := d.db.QueryContext(ctx, d.rebind(`SELECT id, analysis_id, user_id, site, rating, correct, incorrect,
+    resolution, time_to_fix_minutes, comment, created_at
+FROM feedback WHERE analysis_id = ? ORDER BY created_at, id`), analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.Feedback{}
+	for rows.Next() {
+		var (
+			f                  models.Feedback
+			correct, incorrect string
+			timeToFix          sql.NullInt64
+		)
+		err := rows.Scan(&f.ID, &f.AnalysisID, &f.User, &f.Site, &f.Rating, &correct, &incorrect,
+			&f.Resolution, &timeToFix, &f.Comment, &f.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(correct), &f.Correct); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(incorrect), &f.Incorrect); err != nil {
+			return nil, err
+		}
+		if timeToFix.Valid {
+			minutes := int(timeToFix.Int64)
+			f.TimeToFixMinutes = &minutes
+		}
+		f.CreatedAt = f.CreatedAt.UTC()
+		items = append(items, f)
+	}
+	return items, rows.Err()
+}
+
+// ListOutcomes returns feedback joined with the analyses it rates, oldest
+// first.
+func (d *DB) ListOutcomes(ctx context.Context, q OutcomeQuery) ([]models.FeedbackOutcome, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	filter := func(clause string, values ...interface{}) {
+		where = append(where, clause)
+		args = append(args, values...)
+	}
+	if q.Type != "" {
+		filter("a.type = ?", q.Type)
+	}
+	if q.Equipment != "" {
+		filter("LOWER(a.equipment) = LOWER(?)", q.Equipment)
+	}
+	if q.Site != "" {
+		filter("a.site = ?", q.Site)
+	}
+	if !q.AllSites {
+		clause, sites := siteFilter("a.site", q.Sites)
+		filter(clause, sites...)
+	}
+	if !q.From.IsZero() {
+		filter("f.created_at >= ?", q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		filter("f.created_at < ?", q.To.UTC())
+	}
+
+	query := `SELECT a.type, a.equipment, f.rating, f.correct_count, f.incorrect_count, f.time_to_fix_minutes, f.created_at
+FROM feedback f JOIN analyses a ON a.id = f.analysis_id`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY f.created_at, f.id"
+
+	rows, err := d.db.QueryContext(ctx, d.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outcomes []models.FeedbackOutcome
+	for rows.Next() {
+		var (
+			o         models.FeedbackOutcome
+			timeToFix sql.NullInt64
+		)
+		if err := rows.Scan(&o.Type, &o.Equipment, &o.Rating, &o.Correct, &o.Incorrect, &timeToFix, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		if timeToFix.Valid {
+			minutes := int(timeToFix.Int64)
+			o.TimeToFixMinutes = &minutes
+		}
+		o.CreatedAt = o.CreatedAt.UTC()
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, rows.Err()
+}
@@ -0,0 +1,11 @@
+ALTER TABLE analyses ADD COLUMN site TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX analyses_user_id ON analyses (user_id);
+CREATE INDEX analyses_equipment ON analyses (equipment);
+CREATE INDEX analyses_site ON analyses (site);
+
+ALTER TABLE analyses ADD COLUMN search tsvector GENERATED ALWAYS AS (
+    to_tsvector('english', equipment || ' ' || request || ' ' || coalesce(result, ''))
+) STORED;
+
+CREATE INDEX analyses_search ON analyses USING GIN (search);
@@ -0,0 +1,18 @@
+CREATE TABLE users (
+    id            TEXT PRIMARY KEY,
+    username      TEXT NOT NULL UNIQUE,
+    name          TEXT NOT NULL DEFAULT '',
+    role          TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    disabled      BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE approvals (
+    analysis_id TEXT PRIMARY KEY REFERENCES analyses (id),
+    decision    TEXT NOT NULL,
+    comment     TEXT NOT NULL DEFAULT '',
+    user_id     TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,24 @@
+CREATE TABLE audit_log (
+    seq         BIGINT PRIMARY KEY,
+    kind        TEXT NOT NULL,
+    analysis_id TEXT NOT NULL,
+    user_id     TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    entry       TEXT NOT NULL,
+    prev_hash   TEXT NOT NULL,
+    hash        TEXT NOT NULL
+);
+
+CREATE INDEX audit_log_created_at ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_change BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
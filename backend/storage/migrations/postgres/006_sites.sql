@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN sites TEXT NOT NULL DEFAULT '[]';
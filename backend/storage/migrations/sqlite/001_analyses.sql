@@ -0,0 +1,22 @@
+CREATE TABLE analyses (
+    id             TEXT PRIMARY KEY,
+    type           TEXT NOT NULL,
+    request_id     TEXT NOT NULL DEFAULT '',
+    user_id        TEXT NOT NULL DEFAULT '',
+    equipment      TEXT NOT NULL DEFAULT '',
+    risk_level     TEXT NOT NULL DEFAULT '',
+    status         TEXT NOT NULL,
+    error          TEXT NOT NULL DEFAULT '',
+    model          TEXT NOT NULL DEFAULT '',
+    prompt_version TEXT NOT NULL DEFAULT '',
+    request        TEXT NOT NULL,
+    prompt         TEXT NOT NULL DEFAULT '',
+    raw_output     TEXT NOT NULL DEFAULT '',
+    result         TEXT,
+    started_at     TIMESTAMP NOT NULL,
+    completed_at   TIMESTAMP NOT NULL,
+    duration_ms    INTEGER NOT NULL
+);
+
+CREATE INDEX analyses_type_started_at ON analyses (type, started_at);
+CREATE INDEX analyses_started_at ON analyses (started_at);
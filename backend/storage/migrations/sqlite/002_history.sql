@@ -0,0 +1,18 @@
+ALTER TABLE analyses ADD COLUMN site TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX analyses_user_id ON analyses (user_id);
+CREATE INDEX analyses_equipment ON analyses (equipment);
+CREATE INDEX analyses_site ON analyses (site);
+
+CREATE VIRTUAL TABLE analyses_fts USING fts4(content="analyses", equipment, request, result);
+
+CREATE TRIGGER analyses_fts_insert AFTER INSERT ON analyses BEGIN
+    INSERT INTO analyses_fts (docid, equipment, request, result)
+    VALUES (new.rowid, new.equipment, new.request, new.result);
+END;
+
+CREATE TRIGGER analyses_fts_delete BEFORE DELETE ON analyses BEGIN
+    DELETE FROM analyses_fts WHERE docid = old.rowid;
+END;
+
+INSERT INTO analyses_fts (analyses_fts) VALUES ('rebuild');
@@ -0,0 +1,18 @@
+CREATE TABLE feedback (
+    id                  TEXT PRIMARY KEY,
+    analysis_id         TEXT NOT NULL REFERENCES analyses (id),
+    user_id             TEXT NOT NULL DEFAULT '',
+    site                TEXT NOT NULL DEFAULT '',
+    rating              INTEGER NOT NULL,
+    correct             TEXT NOT NULL,
+    incorrect           TEXT NOT NULL,
+    correct_count       INTEGER NOT NULL,
+    incorrect_count     INTEGER NOT NULL,
+    resolution          TEXT NOT NULL DEFAULT '',
+    time_to_fix_minutes INTEGER,
+    comment             TEXT NOT NULL DEFAULT '',
+    created_at          TIMESTAMP NOT NULL
+);
+
+CREATE INDEX feedback_analysis_id ON feedback (analysis_id);
+CREATE INDEX feedback_created_at ON feedback (created_at);
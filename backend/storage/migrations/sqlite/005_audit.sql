@@ -0,0 +1,20 @@
+CREATE TABLE audit_log (
+    seq         INTEGER PRIMARY KEY,
+    kind        TEXT NOT NULL,
+    analysis_id TEXT NOT NULL,
+    user_id     TEXT NOT NULL,
+    created_at  TIMESTAMP NOT NULL,
+    entry       TEXT NOT NULL,
+    prev_hash   TEXT NOT NULL,
+    hash        TEXT NOT NULL
+);
+
+CREATE INDEX audit_log_created_at ON audit_log (created_at);
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
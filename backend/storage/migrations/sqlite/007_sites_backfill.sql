@@ -0,0 +1,3 @@
+-- Accounts with no sites could use every site until access became denied by
+-- default. Keep that for accounts created before then.
+UPDATE users SET sites = '["*"]' WHERE sites = '[]';
@@ -0,0 +1,222 @@
+// Package storage persists analysis records in SQLite, the default, or
+// PostgreSQL. The schema is created and upgraded by embedded migrations.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+	"pcst-ai/backend/models"
+)
+
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+// Store is the persistence boundary used by the handlers.
+type Store interface {
+	SaveAnalysis(ctx context.Context, rec models.AnalysisRecord) error
+	GetAnalysis(ctx context.Context, id string) (models.AnalysisRecord, error)
+	ListAnalyses(ctx context.Context, q HistoryQuery) (models.HistoryPage, error)
+	SaveFeedback(ctx context.Context, f models.Feedback) error
+	ListFeedback(ctx context.Context, analysisID string) ([]models.Feedback, error)
+	ListOutcomes(ctx context.Context, q OutcomeQuery) ([]models.FeedbackOutcome, error)
+	SaveApproval(ctx context.Context, a models.Approval) error
+	CreateUser(ctx context.Context, u models.User, passwordHash string) error
+	UpdateUser(ctx context.Context, u models.User, passwordHash string) error
+	DeleteUser(ctx context.Context, id string) error
+	GetUser(ctx context.Context, id string) (models.User, error)
+	UserByUsername(ctx context.Context, username string) (models.User, string, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	CountUsers(ctx context.Context) (int, error)
+	AppendAudit(ctx context.Context, e models.AuditEntry, key []byte) (models.AuditEntry, error)
+	ScanAudit(ctx context.Context, q AuditQuery, fn func(models.AuditEntry) error) error
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// DB is a Store backed by database/sql.
+type DB struct {
+	db      *sql.DB
+	dialect string
+}
+
+// Open connects to dsn and applies any pending migrations. A postgres:// or
+// postgresql:// URL selects PostgreSQL; anything else is a SQLite file,
+// optionally written as sqlite://path.
+func Open(ctx context.Context, dsn string) (*DB, error) {
+	d := &DB{dialect: DialectSQLite}
+
+	var err error
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		d.dialect = DialectPostgres
+		d.db, err = sql.Open("pgx", dsn)
+	default:
+		path := strings.TrimPrefix(dsn, "sqlite://")
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("creating database directory: %w", err)
+			}
+		}
+		d.db, err = sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on")
+		// SQLite allows one writer; a single connection avoids lock errors.
+		if err == nil {
+			d.db.SetMaxOpenConns(1)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	if err := d.db.PingContext(ctx); err != nil {
+		d.db.Close()
+		return nil, fmt.Errorf("connecting to database: %w", err)
+	}
+	if err := d.migrate(ctx); err != nil {
+		d.db.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *DB) Dialect() string {
+	return d.dialect
+}
+
+func (d *DB) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// migrate applies, in order and each in its own transaction, the embedded
+// migrations for the dialect that schema_migrations does not list yet.
+func (d *DB) migrate(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	applied := map[int]bool{}
+	rows, err := d.db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[v] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	dir := "migrations/" + d.dialect
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return fmt.Errorf("migration %s: name must start with a version number", name)
+		}
+		if applied[version] {
+			continue
+		}
+
+		script, err := fs.ReadFile(migrations, dir+"/"+name)
+		if err != nil {
+			return err
+		}
+		if err := d.apply(ctx, version, name, string(script)); err != nil {
+			return fmt.Errorf("migration %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (d *DB) apply(ctx context.Context, version int, name, script string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Both drivers run a multi-statement script when it has no arguments.
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, d.rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+		version, name, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebind rewrites ? placeholders as $1, $2... for PostgreSQL.
+func (d *DB) rebind(query string) string {
+	if d.dialect != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// siteFilter limits column to sites. No sites match nothing, so a caller
+// granted no site sees nothing rather than everything.
+func siteFilter(column string, sites []string) (string, []interface{}) {
+	if len(sites) == 0 {
+		return "1 = 0", nil
+	}
+	return inList(column, sites)
+}
+
+// inList returns "column IN (?, ...)" and its arguments.
+func inList(column string, values []string) (string, []interface{}) {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")", args
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"pcst-ai/backend/models"
+)
+
+var tracer = otel.Tracer("pcst-ai/backend/storage")
+
+// Traced wraps store so each call records a span named after the method.
+func Traced(store Store, dialect string) Store {
+	return tracedStore{store: store, dialect: dialect}
+}
+
+type tracedStore struct {
+	store   Store
+	dialect string
+}
+
+// traced runs fn in a span for op, marking the span failed when fn fails.
+func traced[T any](ctx context.Context, s tracedStore, op string, fn func(context.Context) (T, error)) (T, error) {
+	ctx, span := tracer.Start(ctx, "storage."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", s.dialect), attribute.String("db.operation", op)),
+	)
+	defer span.End()
+	v, err := fn(ctx)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrUserNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return v, err
+}
+
+// tracedErr is traced for methods that only return an error.
+func tracedErr(ctx context.Context, s tracedStore, op string, fn func(context.Context) error) error {
+	_, err := traced(ctx, s, op, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+func (s tracedStore) SaveAnalysis(ctx context.Context, rec models.AnalysisRecord) error {
+	return tracedErr(ctx, s, "SaveAnalysis", func(ctx context.Context) error { return s.store.SaveAnalysis(ctx, rec) })
+}
+
+func (s tracedStore) GetAnalysis(ctx context.Context, id string) (models.AnalysisRecord, error) {
+	return traced(ctx, s, "GetAnalysis", func(ctx context.Context) (models.AnalysisRecord, error) { return s.store.GetAnalysis(ctx, id) })
+}
+
+func (s tracedStore) ListAnalyses(ctx context.Context, q HistoryQuery) (models.HistoryPage, error) {
+	return traced(ctx, s, "ListAnalyses", func(ctx context.Context) (models.HistoryPage, error) { return s.store.ListAnalyses(ctx, q) })
+}
+
+func (s tracedStore) SaveFeedback(ctx context.Context, f models.Feedback) error {
+	return tracedErr(ctx, s, "SaveFeedback", func(ctx context.Context) error { return s.store.SaveFeedback(ctx, f) })
+}
+
+func (s tracedStore) ListFeedback(ctx context.Context, analysisID string) ([]models.Feedback, error) {
+	return traced(ctx, s, "ListFeedback", func(ctx context.Context) ([]models.Feedback, error) { return s.store.ListFeedback(ctx, analysisID) })
+}
+
+func (s tracedStore) ListOutcomes(ctx context.Context, q OutcomeQuery) ([]models.FeedbackOutcome, error) {
+	return traced(ctx, s, "ListOutcomes", func(ctx context.Context) ([]models.FeedbackOutcome, error) { return s.store.ListOutcomes(ctx, q) })
+}
+
+func (s tracedStore) SaveApproval(ctx context.Context, a models.Approval) error {
+	return tracedErr(ctx, s, "SaveApproval", func(ctx context.Context) error { return s.store.SaveApproval(ctx, a) })
+}
+
+func (s tracedStore) CreateUser(ctx context.Context, u models.User, passwordHash string) error {
+	return tracedErr(ctx, s, "CreateUser", func(ctx context.Context) error { return s.store.CreateUser(ctx, u, passwordHash) })
+}
+
+func (s tracedStore) UpdateUser(ctx context.Context, u models.User, passwordHash string) error {
+	return tracedErr(ctx, s, "UpdateUser", func(ctx context.Context) error { return s.store.UpdateUser(ctx, u, passwordHash) })
+}
+
+func (s tracedStore) DeleteUser(ctx context.Context, id string) error {
+	return tracedErr(ctx, s, "DeleteUser", func(ctx context.Context) error { return s.store.DeleteUser(ctx, id) })
+}
+
+func (s tracedStore) GetUser(ctx context.Context, id string) (models.User, error) {
+	return traced(ctx, s, "GetUser", func(ctx context.Context) (models.User, error) { return s.store.GetUser(ctx, id) })
+}
+
+func (s tracedStore) UserByUsername(ctx context.Context, username string) (models.User, string, error) {
+	var hash string
+	u, err := traced(ctx, s, "UserByUsername", func(ctx context.Context) (models.User, error) {
+		u, h, err := s.store.UserByUsername(ctx, username)
+		hash = h
+		return u, err
+	})
+	return u, hash, err
+}
+
+func (s tracedStore) ListUsers(ctx context.Context) ([]models.User, error) {
+	return traced(ctx, s, "ListUsers", s.store.ListUsers)
+}
+
+func (s tracedStore) CountUsers(ctx context.Context) (int, error) {
+	return traced(ctx, s, "CountUsers", s.store.CountUsers)
+}
+
+func (s tracedStore) AppendAudit(ctx context.Context, e models.AuditEntry, key []byte) (models.AuditEntry, error) {
+	return traced(ctx, s, "AppendAudit", func(ctx context.Context) (models.AuditEntry, error) { return s.store.AppendAudit(ctx, e, key) })
+}
+
+func (s tracedStore) ScanAudit(ctx context.Context, q AuditQuery, fn func(models.AuditEntry) error) error {
+	return tracedErr(ctx, s, "ScanAudit", func(ctx context.Context) error { return s.store.ScanAudit(ctx, q, fn) })
+}
+
+func (s tracedStore) Ping(ctx context.Context) error {
+	return tracedErr(ctx, s, "Ping", s.store.Ping)
+}
+
+func (s tracedStore) Close() error {
+	return s.store.Close()
+}
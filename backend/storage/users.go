@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"pcst-ai/backend/models"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserConflict = errors.New("username is taken")
+)
+
+const userColumns = `id, username, name, role, sites, disabled, created_at, updated_at`
+
+// CreateUser adds a local account with an already hashed password.
+func (d *DB) CreateUser(ctx context.Context, u models.User, passwordHash string) error {
+	if _, _, err := d.UserByUsername(ctx, u.Username); err == nil {
+		return ErrUserConflict
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	sites, err := json.Marshal(nonNilSites(u.Sites))
+	if err != nil {
+		return err
+	}
+	_, err = d.db.ExecContext(ctx, d.rebind(`INSERT INTO users (`+userColumns+`, password_hash)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		u.ID, u.Username, u.Name, u.Role, string(sites), u.Disabled, u.CreatedAt, u.UpdatedAt, passwordHash)
+	return err
+}
+
+// UpdateUser replaces an account. An empty passwordHash keeps the current
+// password.
+func (d *DB) UpdateUser(ctx context.Context, u models.User, passwordHash string) error {
+	if other, _, err := d.UserByUsername(ctx, u.Username); err == nil && other.ID != u.ID {
+		return ErrUserConflict
+	} else if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	sites, err := json.Marshal(nonNilSites(u.Sites))
+	if err != nil {
+		return err
+	}
+	query := `UPDATE users SET username = ?, name = ?, role = ?, sites = ?, disabled = ?, updated_at = ?`
+	args := []interface{}{u.Username, u.Name, u.Role, string(sites), u.Disabled, u.UpdatedAt}
+	if passwordHash != "" {
+		query += `, password_hash = ?`
+		args = append(args, passwordHash)
+	}
+	query += ` WHERE id = ?`
+	args = append(args, u.ID)
+
+	res, err := d.db.ExecContext(ctx, d.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (d *DB) DeleteUser(ctx context.Context, id string) error {
+	res, err := d.db.ExecContext(ctx, d.rebind(`DELETE FROM users WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (d *DB) GetUser(ctx context.Context, id string) (models.User, error) {
+	u, _, err := d.scanUser(d.db.QueryRowContext(ctx, d.rebind(`SELECT `+userColumns+`, password_hash FROM users WHERE id = ?`), id))
+	return u, err
+}
+
+// UserByUsername returns an account and its password hash.
+func (d *DB) UserByUsername(ctx context.Context, username string) (models.User, string, error) {
+	return d.scanUser(d.db.QueryRowContext(ctx, d.rebind(`SELECT `+userColumns+`, password_hash FROM users WHERE username = ?`), username))
+}
+
+// ListUsers returns every local account sorted by username.
+func (d *DB) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+userColumns+`, password_hash FROM users ORDER BY username`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, _, err := d.scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (d *DB) CountUsers(ctx context.Context) (int, error) {
+	var n int
+	err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&n)
+	return n, err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (d *DB) scanUser(row scanner) (models.User, string, error) {
+	var (
+		u           models.User
+		sites, hash string
+	)
+	err := row.Scan(&u.ID, &u.Username, &u.Name, &u.Role, &sites, &u.Disabled, &u.CreatedAt, &u.UpdatedAt, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, "", ErrUserNotFound
+	}
+	if err != nil {
+		return models.User{}, "", err
+	}
+	if err := json.Unmarshal([]byte(sites), &u.Sites); err != nil {
+		return models.User{}, "", err
+	}
+	u.CreatedAt = u.CreatedAt.UTC()
+	u.UpdatedAt = u.UpdatedAt.UTC()
+	return u, hash, nil
+}
+
+func nonNilSites(sites []string) []string {
+	if sites == nil {
+		return []string{}
+	}
+	return sites
+}
+
+// SaveApproval records the sign-off of an analysis, replacing any earlier
+// decision.
+func (d *DB) SaveApproval(ctx context.Context, a models.Approval) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, d.rebind(`DELETE FROM approvals WHERE analysis_id = ?`), a.AnalysisID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, d.rebind(`INSERT INTO approvals (analysis_id, decision, comment, user_id, created_at)
+VALUES (?, ?, ?, ?, ?)`), a.AnalysisID, a.Decision, a.Comment, a.User, a.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *DB) approval(ctx context.Context, analysisID string) (*models.Approval, error) {
+	a := models.Approval{AnalysisID: analysisID}
+	err := d.db.QueryRowContext(ctx, d.rebind(`SELECT decision, comment, user_id, created_at FROM approvals WHERE analysis_id = ?`), analysisID).
+		Scan(&a.Decision, &a.Comment, &a.User, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.CreatedAt = a.CreatedAt.UTC()
+	return &a, nil
+}
@@ -0,0 +1,60 @@
+package units
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Quantity is a value with its unit. It unmarshals from either
+// {"value": 150, "unit": "°F"} or a bare number, which is taken to be in the
+// canonical unit of whatever dimension the field holds. An object without a
+// value is refused.
+type Quantity struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		q.Unit = ""
+		return json.Unmarshal(data, &q.Value)
+	}
+
+	// A missing value must not be read as zero, which is a valid reading.
+	var p struct {
+		Value *float64 `json:"value"`
+		Unit  string   `json:"unit"`
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	if p.Value == nil {
+		return fmt.Errorf("quantity %s has no value", data)
+	}
+	*q = Quantity{Value: *p.Value, Unit: p.Unit}
+	return nil
+}
+
+// Canonical returns the value in the dimension's canonical unit.
+func (q Quantity) Canonical(d Dimension) (float64, error) {
+	return Convert(q.Value, q.Unit, "", d)
+}
+
+// Render expresses a canonical value in the unit system's preferred unit,
+// rounded for display.
+func Render(canonicalValue float64, d Dimension, s System) Quantity {
+	unit := Preferred(d, s)
+	v, err := Convert(canonicalValue, "", unit, d)
+	if err != nil {
+		return Quantity{Value: canonicalValue, Unit: Canonical(d)}
+	}
+	return Quantity{Value: round(v, 3), Unit: unit}
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,168 @@
+// Package units converts engineering quantities between the unit systems
+// used by field instrumentation and datasheets. Every dimension has one
+// canonical unit that the analyzers work in: °C, bar absolute, m/s and
+// mm/y.
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Dimension string
+
+const (
+	Temperature   Dimension = "temperature"
+	Pressure      Dimension = "pressure"
+	Velocity      Dimension = "velocity"
+	CorrosionRate Dimension = "corrosionRate"
+)
+
+type System string
+
+const (
+	Metric   System = "metric"
+	Imperial System = "imperial"
+)
+
+// AtmosphericBar is the standard atmosphere used to move between gauge and
+// absolute pressure.
+const AtmosphericBar = 1.01325
+
+type unit struct {
+	symbol    string
+	dimension Dimension
+	toBase    func(float64) float64
+	fromBase  func(float64) float64
+}
+
+func linear(factor, offset float64) (func(float64) float64, func(float64) float64) {
+	return func(v float64) float64 { return v*factor + offset },
+		func(v float64) float64 { return (v - offset) / factor }
+}
+
+func define(symbol string, d Dimension, factor, offset float64, aliases ...string) {
+	to, from := linear(factor, offset)
+	u := &unit{symbol: symbol, dimension: d, toBase: to, fromBase: from}
+	registry[normalizeName(symbol)] = u
+	for _, a := range aliases {
+		registry[normalizeName(a)] = u
+	}
+}
+
+var registry = map[string]*unit{}
+
+var (
+	canonical = map[Dimension]string{
+		Temperature:   "°C",
+		Pressure:      "bar(a)",
+		Velocity:      "m/s",
+		CorrosionRate: "mm/y",
+	}
+	preferred = map[System]map[Dimension]string{
+		Metric: canonical,
+		Imperial: {
+			Temperature:   "°F",
+			Pressure:      "psi(a)",
+			Velocity:      "ft/s",
+			CorrosionRate: "mpy",
+		},
+	}
+)
+
+func init() {
+	define("°C", Temperature, 1, 0, "C", "degC", "celsius")
+	define("°F", Temperature, 5.0/9, -32*5.0/9, "F", "degF", "fahrenheit")
+	define("K", Temperature, 1, -273.15, "kelvin")
+	define("°R", Temperature, 5.0/9, -273.15, "R", "degR", "rankine")
+
+	// Unqualified pressure units are taken as absolute.
+	define("bar(a)", Pressure, 1, 0, "bar", "bara")
+	define("bar(g)", Pressure, 1, AtmosphericBar, "barg")
+	define("psi(a)", Pressure, 0.0689475729, 0, "psi", "psia")
+	define("psi(g)", Pressure, 0.0689475729, AtmosphericBar, "psig")
+	define("kPa(a)", Pressure, 0.01, 0, "kPa", "kPaa")
+	define("kPa(g)", Pressure, 0.01, AtmosphericBar, "kPag")
+	define("MPa(a)", Pressure, 10, 0, "MPa", "MPaa")
+	define("MPa(g)", Pressure, 10, AtmosphericBar, "MPag")
+	define("Pa", Pressure, 1e-5, 0)
+	define("atm", Pressure, AtmosphericBar, 0)
+
+	define("m/s", Velocity, 1, 0, "mps")
+	define("ft/s", Velocity, 0.3048, 0, "fps", "ft/sec")
+	define("ft/min", Velocity, 0.3048/60, 0, "fpm")
+	define("km/h", Velocity, 1/3.6, 0, "kph")
+
+	define("mm/y", CorrosionRate, 1, 0, "mm/yr", "mm/year", "mmpy")
+	define("µm/y", CorrosionRate, 0.001, 0, "um/y", "um/yr", "µm/yr")
+	define("mpy", CorrosionRate, 0.0254, 0, "mil/y", "mil/yr", "mils/y", "mils/yr")
+}
+
+func normalizeName(name string) string {
+	r := strings.NewReplacer(" ", "", "°", "", "º", "", "(", "", ")", "")
+	name = strings.ToLower(r.Replace(strings.TrimSpace(name)))
+	return strings.TrimPrefix(name, "deg")
+}
+
+func lookup(name string, d Dimension) (*unit, error) {
+	if strings.TrimSpace(name) == "" {
+		name = canonical[d]
+	}
+	u, ok := registry[normalizeName(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", name)
+	}
+	if u.dimension != d {
+		return nil, fmt.Errorf("unit %q is not a %s unit", name, d)
+	}
+	return u, nil
+}
+
+// Canonical returns the unit the analyzers use for a dimension.
+func Canonical(d Dimension) string {
+	return canonical[d]
+}
+
+// Preferred returns the unit a response should be rendered in.
+func Preferred(d Dimension, s System) string {
+	if units, ok := preferred[s]; ok {
+		return units[d]
+	}
+	return canonical[d]
+}
+
+// Convert converts a value between two units of the same dimension. An
+// empty unit means the canonical unit.
+func Convert(value float64, from, to string, d Dimension) (float64, error) {
+	src, err := lookup(from, d)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := lookup(to, d)
+	if err != nil {
+		return 0, err
+	}
+	return dst.fromBase(src.toBase(value)), nil
+}
+
+// Symbol returns the display symbol for a unit name, e.g. "psig" becomes
+// "psi(g)".
+func Symbol(name string, d Dimension) (string, error) {
+	u, err := lookup(name, d)
+	if err != nil {
+		return "", err
+	}
+	return u.symbol, nil
+}
+
+// ParseSystem accepts "metric"/"si" and "imperial"/"us", defaulting to
+// metric when empty.
+func ParseSystem(s string) (System, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "metric", "si":
+		return Metric, nil
+	case "imperial", "us", "usc":
+		return Imperial, nil
+	}
+	return "", fmt.Errorf("unknown unit system %q", s)
+}
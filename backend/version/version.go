@@ -0,0 +1,44 @@
+// Package version identifies the build. Version is set at link time, e.g.
+//
+//	go build -ldflags "-X pcst-ai/backend/version.Version=1.4.0"
+//
+// and the commit comes from the VCS stamp Go embeds when building from a
+// git checkout.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"pcst-ai/backend/models"
+)
+
+// Version is the release this binary was built as.
+var Version = "dev"
+
+var started = time.Now()
+
+// Info describes this binary and when it started.
+func Info() models.BuildInfo {
+	info := models.BuildInfo{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		StartedAt: started,
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range build.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.time":
+			info.CommitTime = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
@@ -0,0 +1,33 @@
+import axios from 'axios'
+import type { Router } from 'vue-router'
+
+const TOKEN_KEY = 'pcst-ai-token'
+
+export const getToken = () => localStorage.getItem(TOKEN_KEY)
+
+export const setToken = (token: string) => localStorage.setItem(TOKEN_KEY, token)
+
+export const clearToken = () => localStorage.removeItem(TOKEN_KEY)
+
+// installAuth sends the stored token with every API request and returns to
+// the sign-in page when the backend rejects it.
+export const installAuth = (router: Router) => {
+  axios.interceptors.request.use((config) => {
+    const token = getToken()
+    if (token) {
+      config.headers.Authorization = `Bearer ${token}`
+    }
+    return config
+  })
+
+  axios.interceptors.response.use(
+    (response) => response,
+    (error) => {
+      if (error.response?.status === 401 && router.currentRoute.value.name !== 'login') {
+        clearToken()
+        router.push({ name: 'login', query: { redirect: router.currentRoute.value.fullPath } })
+      }
+      return Promise.reject(error)
+    },
+  )
+}
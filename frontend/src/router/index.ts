@@ -4,10 +4,17 @@ import TroubleshootingView from '../views/TroubleshootingView.vue'
 import CorrosionAIView from '../views/CorrosionAIView.vue'
 import SafetyAdvisorView from '../views/SafetyAdvisorView.vue'
 import VCRAView from '../views/VCRAView.vue'
+import LoginView from '../views/LoginView.vue'
+import { installAuth } from '../auth'
 
 const router = createRouter({
   history: createWebHistory(import.meta.env.BASE_URL),
   routes: [
+    {
+      path: '/login',
+      name: 'login',
+      component: LoginView,
+    },
     {
       path: '/',
       name: 'home',
@@ -36,4 +43,8 @@ const router = createRouter({
   ],
 })
 
+// Pages go to sign-in when the backend rejects a request, so nothing
+// changes when authentication is disabled.
+installAuth(router)
+
 export default router